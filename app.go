@@ -1,20 +1,33 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/filiadielias/kmpr-test/src/general"
 	"github.com/filiadielias/kmpr-test/src/handler"
 	"github.com/filiadielias/kmpr-test/src/helper/db"
 	"github.com/filiadielias/kmpr-test/src/helper/elastic"
 	"github.com/filiadielias/kmpr-test/src/helper/redis"
+	"github.com/filiadielias/kmpr-test/src/news"
+
+	"github.com/elastic/go-elasticsearch"
 )
 
 var kmpr *general.Module
 
+// Reindex published news instead of starting the API, e.g. after new fields are added to the mapping
+var reindex = flag.Bool("reindex", false, "add all published news to elasticsearch and exit")
+
+const reindexBatchSize = 500
+
 func init() {
 	var c general.Config
 	if err := initConfig(&c); err != nil {
@@ -37,7 +50,36 @@ func init() {
 		return
 	}
 
-	red := redis.Connect(c.Redis.Host, c.Redis.Port)
+	// News are taken from database while elasticsearch is not available
+	if err := initIndices(es); err != nil {
+		log.Println("Fail to create elasticsearch index: ", err)
+	}
+
+	rc := c.Redis
+	pool := redis.InitPool(rc.Host, rc.Port).
+		Credential(rc.Password).
+		Database(rc.DB).
+		Timeout(
+			time.Duration(rc.ConnectTimeoutMS)*time.Millisecond,
+			time.Duration(rc.ReadTimeoutMS)*time.Millisecond,
+			time.Duration(rc.WriteTimeoutMS)*time.Millisecond,
+		).
+		Size(rc.MaxIdle, rc.MaxActive, time.Duration(rc.IdleTimeoutSeconds)*time.Second, rc.Wait).
+		Sentinel(rc.Sentinel.MasterName, rc.Sentinel.Addresses, rc.Sentinel.Password)
+	if rc.TLS {
+		pool.TLS(rc.TLSSkipVerify)
+	}
+
+	red, err := pool.Connect()
+	if err != nil {
+		log.Fatal("Invalid redis config: ", err)
+		return
+	}
+
+	// Redis outage is handled by cache, the API is started without redis
+	if err := redis.Ping(red); err != nil {
+		log.Println("Redis is not available: ", err)
+	}
 
 	general.New(dbconn, c, es, red)
 
@@ -63,7 +105,46 @@ func initConfig(c *general.Config) error {
 	return c.Parse(confFile)
 }
 
+// Create elasticsearch indices if not exists and add new mapping fields, using mapping from files/elasticsearch
+func initIndices(es *elasticsearch.Client) error {
+	for _, index := range []string{"news"} {
+		b, err := ioutil.ReadFile(fmt.Sprintf("./files/elasticsearch/%s.json", index))
+		if err != nil {
+			return err
+		}
+
+		if err := elastic.CreateIndex(es, index, bytes.NewReader(b)); err != nil {
+			return err
+		}
+
+		// Index may be created by older version, add new fields to the mapping.
+		// Existing documents must be reindexed to fill the new fields
+		var settings struct {
+			Mappings json.RawMessage `json:"mappings"`
+		}
+		if err := json.Unmarshal(b, &settings); err != nil {
+			return err
+		}
+		if err := elastic.PutMapping(es, index, bytes.NewReader(settings.Mappings)); err != nil {
+			log.Printf("Fail to update %s mapping: %v", index, err)
+		}
+	}
+
+	return nil
+}
+
 func main() {
+	flag.Parse()
+
+	if *reindex {
+		count, err := news.ReindexNews(reindexBatchSize)
+		if err != nil {
+			log.Fatalf("Fail to reindex news after %d news: %v", count, err)
+		}
+
+		log.Printf("%d news reindexed", count)
+		return
+	}
 
 	address := fmt.Sprintf("%s:%d", kmpr.Config.App.Host, kmpr.Config.App.Port)
 	router := handler.GetHandlers()
@@ -73,6 +154,9 @@ func main() {
 		log.Fatal(handler.StartNSQConsumer(kmpr.Config.NSQ.Consumer.Host, kmpr.Config.NSQ.Consumer.Port))
 	}()
 
+	//start background jobs
+	go handler.StartJobs()
+
 	log.Fatal(http.ListenAndServe(address, router))
 
 }
@@ -0,0 +1,31 @@
+-- Authors table, news.author is kept as author display name
+CREATE TABLE IF NOT EXISTS authors (
+	id serial PRIMARY KEY,
+	name varchar(255) NOT NULL,
+	slug varchar(255) NOT NULL UNIQUE,
+	bio text NOT NULL DEFAULT '',
+	created timestamp NOT NULL DEFAULT now()
+);
+
+ALTER TABLE news ADD COLUMN IF NOT EXISTS author_id integer REFERENCES authors(id);
+CREATE INDEX IF NOT EXISTS news_author_id_idx ON news(author_id);
+
+-- Migrate existing author strings, different spellings with the same slug
+-- (e.g. "J.R.R. Tolkien" and "j r r tolkien") become the same author
+INSERT INTO authors(name, slug)
+SELECT DISTINCT ON (slug) name, slug
+FROM (
+	SELECT trim(author) AS name,
+		trim(both '-' from regexp_replace(lower(trim(author)), '[^a-z0-9]+', '-', 'g')) AS slug,
+		created
+	FROM news
+	WHERE trim(author) <> ''
+) a
+WHERE slug <> ''
+ORDER BY slug, created
+ON CONFLICT (slug) DO NOTHING;
+
+UPDATE news n SET author_id = a.id
+FROM authors a
+WHERE n.author_id IS NULL
+	AND a.slug = trim(both '-' from regexp_replace(lower(trim(n.author)), '[^a-z0-9]+', '-', 'g'));
@@ -0,0 +1,11 @@
+ALTER TABLE news ADD COLUMN IF NOT EXISTS title varchar(255) NOT NULL DEFAULT '';
+ALTER TABLE news ADD COLUMN IF NOT EXISTS slug varchar(255);
+ALTER TABLE news ADD COLUMN IF NOT EXISTS summary text NOT NULL DEFAULT '';
+
+-- Existing news have no title, generate slug from the beginning of body
+-- and add id to make sure it is unique
+UPDATE news SET slug = trim(both '-' from regexp_replace(lower(left(body, 60)), '[^a-z0-9]+', '-', 'g')) || '-' || id
+WHERE slug IS NULL;
+
+ALTER TABLE news ALTER COLUMN slug SET NOT NULL;
+ALTER TABLE news ADD CONSTRAINT news_slug_key UNIQUE (slug);
@@ -0,0 +1,4 @@
+-- Existing news are already public, new news start as draft
+ALTER TABLE news ADD COLUMN IF NOT EXISTS status varchar(20) NOT NULL DEFAULT 'published';
+ALTER TABLE news ALTER COLUMN status SET DEFAULT 'draft';
+CREATE INDEX IF NOT EXISTS news_status_idx ON news(status);
@@ -0,0 +1,3 @@
+ALTER TABLE news ADD COLUMN IF NOT EXISTS publish_at timestamptz;
+CREATE INDEX IF NOT EXISTS news_scheduled_publish_at_idx ON news(publish_at) WHERE status = 'scheduled';
+UPDATE news SET publish_at = created WHERE status = 'published' AND publish_at IS NULL;
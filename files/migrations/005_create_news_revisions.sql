@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS news_revisions (
+	id serial PRIMARY KEY,
+	news_id integer NOT NULL REFERENCES news(id),
+	rev integer NOT NULL,
+	author_id integer REFERENCES authors(id),
+	author text NOT NULL DEFAULT '',
+	title varchar(255) NOT NULL DEFAULT '',
+	summary text NOT NULL DEFAULT '',
+	body text NOT NULL DEFAULT '',
+	editor varchar(255) NOT NULL DEFAULT '',
+	created timestamp NOT NULL DEFAULT now(),
+	UNIQUE (news_id, rev)
+);
+
+-- Current version of existing news as the first revision
+INSERT INTO news_revisions(news_id, rev, author_id, author, title, summary, body, created)
+SELECT id, 1, author_id, author, title, summary, body, created FROM news n
+WHERE NOT EXISTS (SELECT 1 FROM news_revisions r WHERE r.news_id = n.id);
@@ -0,0 +1,2 @@
+ALTER TABLE news ADD COLUMN IF NOT EXISTS deleted_at timestamp;
+CREATE INDEX IF NOT EXISTS news_deleted_at_idx ON news(deleted_at) WHERE deleted_at IS NOT NULL;
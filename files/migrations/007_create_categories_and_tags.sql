@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS categories (
+	id serial PRIMARY KEY,
+	name varchar(100) NOT NULL,
+	slug varchar(100) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS tags (
+	id serial PRIMARY KEY,
+	name varchar(100) NOT NULL,
+	slug varchar(100) NOT NULL UNIQUE
+);
+
+ALTER TABLE news ADD COLUMN IF NOT EXISTS category_id integer REFERENCES categories(id);
+CREATE INDEX IF NOT EXISTS news_category_id_idx ON news(category_id);
+
+CREATE TABLE IF NOT EXISTS news_tags (
+	news_id integer NOT NULL REFERENCES news(id),
+	tag_id integer NOT NULL REFERENCES tags(id),
+	PRIMARY KEY (news_id, tag_id)
+);
+CREATE INDEX IF NOT EXISTS news_tags_tag_id_idx ON news_tags(tag_id);
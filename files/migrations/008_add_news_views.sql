@@ -0,0 +1,2 @@
+ALTER TABLE news ADD COLUMN IF NOT EXISTS views bigint NOT NULL DEFAULT 0;
+ALTER TABLE news ADD COLUMN IF NOT EXISTS unique_views bigint NOT NULL DEFAULT 0;
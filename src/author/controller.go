@@ -0,0 +1,68 @@
+// Package author contains business logic from author, store to database, etc
+package author
+
+import (
+	"errors"
+
+	"github.com/filiadielias/kmpr-test/src/general"
+	"github.com/filiadielias/kmpr-test/src/helper/db"
+)
+
+// GetAuthors getting list of authors by page, ordered by name
+func GetAuthors(page, size int) (as Authors, err error) {
+	if size <= 0 {
+		return as, errors.New("Invalid size number")
+	}
+
+	if page <= 0 {
+		page = 1
+	}
+
+	qb := db.QueryBuilder{}
+	qb.Page = page
+	qb.Limit = size
+	qb.AddSort("name", "asc")
+	qb.AddSort("id", "asc")
+
+	// Empty page is not an error
+	if err := as.getFromDB(&qb); err != nil && err != ErrNotFound {
+		return as, err
+	}
+
+	return as, nil
+}
+
+// GetAuthor getting author detail by id
+func GetAuthor(id int) (a Author, err error) {
+	if id <= 0 {
+		return a, ErrNotFound
+	}
+
+	qb := db.QueryBuilder{}
+	qb.AddFilter("id", id, "=")
+
+	err = a.getFromDB(&qb)
+	return a, err
+}
+
+// GetOrCreateAuthor getting author by name, authors are matched by slug
+// so different spellings of the same name are the same author.
+// New author is created if not exists
+func GetOrCreateAuthor(name string) (a Author, err error) {
+	a.Name = name
+	a.Slug = general.Slugify(name)
+	if len(a.Slug) == 0 {
+		return a, ErrInvalidName
+	}
+
+	qb := db.QueryBuilder{}
+	qb.AddFilter("slug", a.Slug, "=")
+
+	err = a.getFromDB(&qb)
+	if err != ErrNotFound {
+		return a, err
+	}
+
+	err = a.insert()
+	return a, err
+}
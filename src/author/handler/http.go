@@ -0,0 +1,66 @@
+// Package handler contains http handlers for author
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/filiadielias/kmpr-test/src/author"
+	"github.com/filiadielias/kmpr-test/src/general"
+	writer_lib "github.com/filiadielias/kmpr-test/src/helper/writer"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var kmpr *general.Module
+
+func init() {
+	kmpr = &general.KMPR
+}
+
+// GetAuthorsHandler : get authors by page number
+func GetAuthorsHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	writer := writer_lib.New(w)
+
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+	size = kmpr.Config.PageSize(size)
+
+	as, err := author.GetAuthors(page, size)
+	if err != nil {
+		log.Println(err)
+		writer.Error(err)
+		return
+	}
+
+	writer.Success(as)
+}
+
+// GetAuthorHandler : get author detail by id
+func GetAuthorHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	writer := writer_lib.New(w)
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		writer.NotFound(author.ErrNotFound)
+		return
+	}
+
+	a, err := author.GetAuthor(id)
+	if err == author.ErrNotFound {
+		writer.NotFound(err)
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		writer.Error(err)
+		return
+	}
+
+	writer.Success(a)
+}
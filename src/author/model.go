@@ -0,0 +1,96 @@
+// Package author contains business logic from author, store to database, etc
+package author
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/filiadielias/kmpr-test/src/general"
+	"github.com/filiadielias/kmpr-test/src/helper/db"
+
+	// PostgreSQL driver
+	_ "github.com/lib/pq"
+)
+
+var kmpr *general.Module
+
+func init() {
+	kmpr = &general.KMPR
+}
+
+// Error list
+var (
+	ErrNotFound    = fmt.Errorf("Author not Found")
+	ErrInvalidName = fmt.Errorf("Invalid author name")
+)
+
+// Authors is collection of Author
+type Authors []Author
+
+// Author represents author information
+type Author struct {
+	ID      int       `json:"id" db:"id"`
+	Name    string    `json:"name" db:"name"`
+	Slug    string    `json:"slug" db:"slug"`
+	Bio     string    `json:"bio" db:"bio"`
+	Created time.Time `json:"created" db:"created"`
+}
+
+// Insert author, if slug already exists get the existing author instead
+func (a *Author) insert() error {
+	tx := kmpr.DB.MustBegin()
+	err := tx.QueryRowx("INSERT INTO authors(name,slug,bio) values($1,$2,$3) "+
+		"ON CONFLICT (slug) DO UPDATE SET slug = EXCLUDED.slug "+
+		"returning id,name,slug,bio,created", a.Name, a.Slug, a.Bio).StructScan(a)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (as *Authors) getFromDB(qb *db.QueryBuilder) error {
+
+	qb.Query = "select id,name,slug,bio,created from authors"
+
+	query, params := qb.GetQuery()
+
+	rows, err := kmpr.DB.Queryx(query, params...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a Author
+
+		if err := rows.StructScan(&a); err != nil {
+			return err
+		}
+
+		*as = append(*as, a)
+	}
+
+	if len(*as) == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (a *Author) getFromDB(qb *db.QueryBuilder) error {
+
+	// Call Authors get function
+	var as Authors
+	if err := as.getFromDB(qb); err != nil {
+		return err
+	}
+
+	*a = as[0]
+
+	return nil
+}
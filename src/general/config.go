@@ -14,6 +14,21 @@ type Config struct {
 		Port     int    `json:"port"`
 		Protocol string `json:"protocol"`
 	} `json:"app"`
+	Pagination struct {
+		DefaultSize int `json:"default_size"`
+		MaxSize     int `json:"max_size"`
+	} `json:"pagination"`
+	Trash struct {
+		RetentionDays        int `json:"retention_days"`
+		PurgeIntervalMinutes int `json:"purge_interval_minutes"`
+	} `json:"trash"`
+	Views struct {
+		FlushIntervalSeconds int `json:"flush_interval_seconds"`
+	} `json:"views"`
+
+	// Addresses or CIDRs of api gateway / load balancers, only they can set identity and visitor headers
+	TrustedProxies []string `json:"trusted_proxies"`
+
 	Database struct {
 		Host     string `json:"host"`
 		Port     int    `json:"port"`
@@ -26,9 +41,41 @@ type Config struct {
 		Port int    `json:"port"`
 	} `json:"elasticsearch"`
 	Redis struct { //redis
-		Host string `json:"host"`
-		Port int    `json:"port"`
+		Host          string `json:"host"`
+		Port          int    `json:"port"`
+		Password      string `json:"password"`
+		DB            int    `json:"db"`
+		TLS           bool   `json:"tls"`
+		TLSSkipVerify bool   `json:"tls_skip_verify"`
+		// Pool settings, zero max active means unlimited
+		MaxIdle            int  `json:"max_idle"`
+		MaxActive          int  `json:"max_active"`
+		IdleTimeoutSeconds int  `json:"idle_timeout_seconds"`
+		Wait               bool `json:"wait"`
+		// Timeouts in milliseconds
+		ConnectTimeoutMS int `json:"connect_timeout_ms"`
+		ReadTimeoutMS    int `json:"read_timeout_ms"`
+		WriteTimeoutMS   int `json:"write_timeout_ms"`
+		// If master name is set, master address is taken from sentinels instead of host and port
+		Sentinel struct {
+			MasterName string   `json:"master_name"`
+			Addresses  []string `json:"addresses"`
+			Password   string   `json:"password"`
+		} `json:"sentinel"`
 	} `json:"redis"`
+	Cache struct {
+		// In-process cache in front of redis, disabled if max items is zero
+		LocalMaxItems   int `json:"local_max_items"`
+		LocalTTLSeconds int `json:"local_ttl_seconds"`
+		// Cache is not used for cooldown after threshold consecutive failures
+		BreakerThreshold       int `json:"breaker_threshold"`
+		BreakerCooldownSeconds int `json:"breaker_cooldown_seconds"`
+		// Serialization format (json or msgpack) and compression (none, gzip or snappy) of new values,
+		// values smaller than min compress size bytes are not compressed
+		Format          string `json:"format"`
+		Compression     string `json:"compression"`
+		MinCompressSize int    `json:"min_compress_size"`
+	} `json:"cache"`
 	NSQ struct {
 		Producer struct { //producer host
 			Host string `json:"host"`
@@ -54,3 +101,22 @@ func (c *Config) Parse(filename string) error {
 
 	return nil
 }
+
+// PageSize : get valid page size, use default size if size is zero or below
+// and limit it to max size
+func (c *Config) PageSize(size int) int {
+	if size <= 0 {
+		size = c.Pagination.DefaultSize
+	}
+
+	// Default value if not configured
+	if size <= 0 {
+		size = 10
+	}
+
+	if c.Pagination.MaxSize > 0 && size > c.Pagination.MaxSize {
+		size = c.Pagination.MaxSize
+	}
+
+	return size
+}
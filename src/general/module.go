@@ -3,6 +3,12 @@
 package general
 
 import (
+	"log"
+	"time"
+
+	"github.com/filiadielias/kmpr-test/src/helper/cache"
+	"github.com/filiadielias/kmpr-test/src/helper/proxy"
+
 	"github.com/elastic/go-elasticsearch"
 	"github.com/gomodule/redigo/redis"
 	"github.com/jmoiron/sqlx"
@@ -11,20 +17,73 @@ import (
 // KMPR is a global variable used for getting config values, establish db, redis and elasticsearch connections.
 var KMPR Module
 
-// Module stores DB connection, configuration, redis pool and elasticsearchclient.
+// Module stores DB connection, configuration, redis pool, cache, elasticsearchclient and trusted proxies.
 type Module struct {
-	DB     *sqlx.DB
-	Config Config
-	ES     *elasticsearch.Client
-	Redis  *redis.Pool
+	DB             *sqlx.DB
+	Config         Config
+	ES             *elasticsearch.Client
+	Redis          *redis.Pool
+	Cache          cache.Cache
+	TrustedProxies proxy.Trusted
 }
 
-// New is adding config and connections to global module
+// New is adding config and connections to global module, redis is used as cache
 func New(db *sqlx.DB, config Config, es *elasticsearch.Client, red *redis.Pool) {
 	KMPR = Module{
-		DB:     db,
-		Config: config,
-		ES:     es,
-		Redis:  red,
+		DB:             db,
+		Config:         config,
+		ES:             es,
+		Redis:          red,
+		Cache:          newCache(config, red),
+		TrustedProxies: proxy.ParseTrusted(config.TrustedProxies),
+	}
+}
+
+// Redis cache, with in-process cache in front of it if enabled.
+// Cache failures are handled as cache misses, and cache is not used for a while after repeated failures
+func newCache(config Config, red *redis.Pool) cache.Cache {
+	// Values are decoded by their format, so changing codec does not need flushing the cache
+	codec, err := cache.ParseCodec(config.Cache.Format, config.Cache.Compression)
+	if err != nil {
+		log.Printf("cache values are encoded as json: %v", err)
+	} else {
+		if config.Cache.MinCompressSize > 0 {
+			codec.MinCompressSize = config.Cache.MinCompressSize
+		}
+		cache.SetCodec(codec)
 	}
+
+	r := cache.NewRedis(red)
+
+	var c cache.Cache = r
+
+	if config.Cache.LocalMaxItems > 0 {
+		ttl := time.Duration(config.Cache.LocalTTLSeconds) * time.Second
+		if ttl <= 0 {
+			ttl = 5 * time.Second
+		}
+
+		c = cache.NewTiered(r, config.Cache.LocalMaxItems, ttl)
+	}
+
+	threshold := config.Cache.BreakerThreshold
+	if threshold <= 0 {
+		threshold = 5
+	}
+
+	cooldown := time.Duration(config.Cache.BreakerCooldownSeconds) * time.Second
+	if cooldown <= 0 {
+		cooldown = 30 * time.Second
+	}
+
+	b := cache.NewBreaker(c, threshold, cooldown)
+	b.OnStateChange = func(open bool, err error) {
+		if open {
+			log.Printf("cache is not used for %s: %v", cooldown, err)
+		} else {
+			log.Println("cache is available")
+		}
+	}
+
+	return b
 }
@@ -8,6 +8,8 @@ import (
 	"encoding/json"
 	"io"
 	"io/ioutil"
+	"strings"
+	"unicode"
 )
 
 // JSONUnmarshal : unmarshal json directly from Reader
@@ -46,3 +48,23 @@ func GobDecode(b []byte, data interface{}) error {
 
 	return nil
 }
+
+// Slugify : convert text into url friendly slug, e.g. "J.R.R. Tolkien" into "j-r-r-tolkien"
+func Slugify(s string) string {
+	var b strings.Builder
+
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(r)
+			dash = false
+		case !dash && b.Len() > 0:
+			// Replace other characters with single dash
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+
+	return strings.TrimSuffix(b.String(), "-")
+}
@@ -2,6 +2,9 @@
 package handler
 
 import (
+	"net/http"
+
+	author_handler "github.com/filiadielias/kmpr-test/src/author/handler"
 	news_handler "github.com/filiadielias/kmpr-test/src/news/handler"
 
 	"github.com/julienschmidt/httprouter"
@@ -13,14 +16,51 @@ func initHandlers() *httprouter.Router {
 	router := httprouter.New()
 	router.POST("/news", news_handler.AddNewsHandler)
 	router.GET("/news", news_handler.GetNewsHandler)
+	router.PUT("/news/:id", news_handler.UpdateNewsHandler)
+	router.PUT("/news/:id/status", news_handler.ChangeNewsStatusHandler)
+	router.DELETE("/news/:id", news_handler.DeleteNewsHandler)
+	router.POST("/news/:id/restore", news_handler.RestoreNewsHandler)
+	router.POST("/news/:id/views", news_handler.AddViewHandler)
+
+	// /news/trash, /news/suggest, /news/trending
+	router.GET("/news/:id", dispatch("id", routes{
+		"trash":    news_handler.GetTrashHandler,
+		"suggest":  news_handler.GetSuggestHandler,
+		"trending": news_handler.GetTrendingHandler,
+	}, notFound))
+
+	// /news/slug/:slug, /news/status/:status, /news/:id/revisions, /news/:id/related
+	router.GET("/news/:id/:sub", dispatch("id", routes{
+		"slug":   rename(news_handler.GetNewsBySlugHandler, "sub", "slug"),
+		"status": rename(news_handler.GetNewsByStatusHandler, "sub", "status"),
+	}, dispatch("sub", routes{
+		"revisions": news_handler.GetRevisionsHandler,
+		"related":   news_handler.GetRelatedNewsHandler,
+	}, notFound)))
+	// /news/:id/revisions/diff
+	router.GET("/news/:id/:sub/diff", dispatch("sub", routes{
+		"revisions": news_handler.DiffRevisionsHandler,
+	}, notFound))
+	router.POST("/news/:id/revisions/:rev/restore", news_handler.RestoreRevisionHandler)
+
+	router.GET("/authors", author_handler.GetAuthorsHandler)
+	router.GET("/authors/:id", author_handler.GetAuthorHandler)
+	router.GET("/authors/:id/news", news_handler.GetAuthorNewsHandler)
+
+	router.GET("/cache/stats", getCacheStatsHandler)
 
 	return router
 }
 
 // GetHandlers : Get endpoint handlers
 // global middleware handlers should be added here
-func GetHandlers() *httprouter.Router {
-	return initHandlers()
+func GetHandlers() http.Handler {
+	return trustedIdentity(initHandlers())
+}
+
+// StartJobs : start background jobs, blocks forever
+func StartJobs() {
+	news_handler.InitJobs()
 }
 
 // StartNSQConsumer : Similar to http.ListenAndServe, but for NSQ consumer
@@ -0,0 +1,32 @@
+// Package handler : Initialize http handlers and NSQ consumers
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/filiadielias/kmpr-test/src/general"
+	writer_lib "github.com/filiadielias/kmpr-test/src/helper/writer"
+)
+
+// Identity headers are set by api gateway after the user is authenticated
+var identityHeaders = []string{"X-User-Role", "X-User-ID"}
+
+var errUntrustedIdentity = fmt.Errorf("User role and id headers are only accepted from api gateway")
+
+// trustedIdentity : reject requests which send identity headers without passing through
+// trusted proxy (trusted_proxies config), so clients can not choose their own role
+func trustedIdentity(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !general.KMPR.TrustedProxies.FromTrusted(r) {
+			for _, header := range identityHeaders {
+				if len(r.Header.Get(header)) > 0 {
+					writer_lib.New(w).Forbidden(errUntrustedIdentity)
+					return
+				}
+			}
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,48 @@
+// Package handler : Initialize http handlers and NSQ consumers
+package handler
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// routes maps static path segment to handler
+type routes map[string]httprouter.Handle
+
+// dispatch : httprouter does not allow static and parameter routes on the same path segment
+// (e.g. /news/slug/:slug and /news/:id/revisions). Those routes are registered once
+// using parameter (e.g. /news/:id/:sub), then dispatched by the parameter value.
+// fallback is used if there is no static route matched
+func dispatch(param string, static routes, fallback httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		if h, ok := static[ps.ByName(param)]; ok {
+			h(w, r, ps)
+			return
+		}
+
+		fallback(w, r, ps)
+	}
+}
+
+// rename : rename route parameter, so handler can use its own parameter name
+// when it is registered using dispatch (e.g. :sub to :slug)
+func rename(h httprouter.Handle, from, to string) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		params := make(httprouter.Params, len(ps))
+		copy(params, ps)
+
+		for i := range params {
+			if params[i].Key == from {
+				params[i].Key = to
+			}
+		}
+
+		h(w, r, params)
+	}
+}
+
+// notFound : default fallback for dispatch
+func notFound(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	http.NotFound(w, r)
+}
@@ -0,0 +1,29 @@
+// Package handler : Initialize http handlers and NSQ consumers
+package handler
+
+import (
+	"net/http"
+
+	"github.com/filiadielias/kmpr-test/src/general"
+	"github.com/filiadielias/kmpr-test/src/helper/cache"
+	writer_lib "github.com/filiadielias/kmpr-test/src/helper/writer"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// cacheStats is implemented by cache which counts hits and misses per tier
+type cacheStats interface {
+	Stats() map[string]cache.Stats
+}
+
+// getCacheStatsHandler : get cache hit and miss counters per tier since the instance started
+func getCacheStatsHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	writer := writer_lib.New(w)
+
+	stats := map[string]cache.Stats{}
+	if c, ok := general.KMPR.Cache.(cacheStats); ok {
+		stats = c.Stats()
+	}
+
+	writer.Success(stats)
+}
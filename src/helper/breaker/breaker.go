@@ -0,0 +1,104 @@
+// Package breaker contains circuit breaker, used for not calling unavailable service for a while
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+// Breaker : after threshold consecutive failures, the circuit is open for cooldown duration
+// (service must not be called). After cooldown, a single call is tried (half-open),
+// the circuit is closed if the call succeeds, or open again if it fails
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	// OnStateChange is called when the circuit is opened or closed
+	OnStateChange func(open bool, err error)
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool      // a call is tried after cooldown, other calls are not allowed
+	probeAt   time.Time // probe without result is retried after cooldown
+}
+
+// New : create circuit breaker, threshold below one is one
+func New(threshold int, cooldown time.Duration) *Breaker {
+	if threshold <= 0 {
+		threshold = 1
+	}
+
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// Allow : check if service can be called. After cooldown only one call is allowed until its result
+// is recorded (Success or Failure)
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+
+	now := time.Now()
+	if now.Before(b.openUntil) || (b.probing && now.Before(b.probeAt.Add(b.cooldown))) {
+		return false
+	}
+
+	b.probing = true
+	b.probeAt = now
+	return true
+}
+
+// Open : check if the circuit is open (service must not be called), the half-open call is not taken
+func (b *Breaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.failures >= b.threshold && (time.Now().Before(b.openUntil) || b.probing)
+}
+
+// Success : record successful call, returns true if the circuit is closed by this call
+func (b *Breaker) Success() bool {
+	b.mu.Lock()
+	closed := b.failures >= b.threshold
+	b.failures = 0
+	b.probing = false
+	b.mu.Unlock()
+
+	if closed && b.OnStateChange != nil {
+		b.OnStateChange(false, nil)
+	}
+
+	return closed
+}
+
+// Failure : record failed call, the circuit is open if failures reach threshold
+func (b *Breaker) Failure(err error) {
+	b.mu.Lock()
+	b.failures++
+	opened := b.failures == b.threshold
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+	b.probing = false
+	b.mu.Unlock()
+
+	if opened && b.OnStateChange != nil {
+		b.OnStateChange(true, err)
+	}
+}
+
+// Done : record call result, nil error is success
+func (b *Breaker) Done(err error) {
+	if err != nil {
+		b.Failure(err)
+		return
+	}
+	b.Success()
+}
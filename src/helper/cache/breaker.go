@@ -0,0 +1,189 @@
+// Package cache contains cache interface with redis and in-memory implementations
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"github.com/filiadielias/kmpr-test/src/helper/breaker"
+)
+
+// Breaker is circuit breaker in front of a cache. After threshold consecutive failures,
+// the cache is not called for cooldown duration (circuit is open):
+//   - reads are cache misses, writes are skipped, and locks are always acquired
+//   - deleted keys and invalidated tags are deleted again when the cache is available,
+//     so stale values are not served after the outage
+//
+// After cooldown, a single call is tried, the circuit is closed if it succeeds
+type Breaker struct {
+	*breaker.Breaker
+	cache Cache
+
+	mu          sync.Mutex
+	pendingKeys map[string]struct{}
+	pendingTags map[string]struct{}
+}
+
+// NewBreaker : create circuit breaker in front of the cache
+func NewBreaker(c Cache, threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		Breaker:     breaker.New(threshold, cooldown),
+		cache:       c,
+		pendingKeys: map[string]struct{}{},
+		pendingTags: map[string]struct{}{},
+	}
+}
+
+// Get value of the key, returns ErrMiss if the circuit is open
+func (b *Breaker) Get(key string, data interface{}) error {
+	if !b.Allow() {
+		return ErrMiss
+	}
+
+	err := b.cache.Get(key, data)
+	b.done(err)
+	return err
+}
+
+// Set value of the key, skipped if the circuit is open
+func (b *Breaker) Set(key string, data interface{}, ttl time.Duration, tags ...string) error {
+	if !b.Allow() {
+		return nil
+	}
+
+	err := b.cache.Set(key, data, ttl, tags...)
+	b.done(err)
+	return err
+}
+
+// Delete keys, keys are deleted later if the circuit is open or deleting failed
+func (b *Breaker) Delete(keys ...string) error {
+	if !b.Allow() {
+		b.addPending(keys, nil)
+		return nil
+	}
+
+	err := b.cache.Delete(keys...)
+	if err != nil {
+		b.addPending(keys, nil)
+	}
+	b.done(err)
+	return err
+}
+
+// Invalidate delete all keys of the tags, tags are invalidated later if the circuit is open
+// or invalidating failed
+func (b *Breaker) Invalidate(tags ...string) error {
+	if !b.Allow() {
+		b.addPending(nil, tags)
+		return nil
+	}
+
+	err := b.cache.Invalidate(tags...)
+	if err != nil {
+		b.addPending(nil, tags)
+	}
+	b.done(err)
+	return err
+}
+
+// GetMulti get values of the keys, returns no value if the circuit is open
+func (b *Breaker) GetMulti(keys []string) (Values, error) {
+	if !b.Allow() {
+		return Values{}, nil
+	}
+
+	values, err := b.cache.GetMulti(keys)
+	b.done(err)
+	return values, err
+}
+
+// TryLock acquire lock of the key if the cache supports Locker.
+// Lock is always acquired if the circuit is open, or the cache does not support Locker
+func (b *Breaker) TryLock(key string, ttl time.Duration) (unlock func(), ok bool, err error) {
+	l, ok := b.cache.(Locker)
+	if !ok || !b.Allow() {
+		return func() {}, true, nil
+	}
+
+	unlock, ok, err = l.TryLock(key, ttl)
+	b.done(err)
+	return unlock, ok, err
+}
+
+// Locked check if the lock of the key is held, lock is not held if the circuit is open
+func (b *Breaker) Locked(key string) (bool, error) {
+	l, ok := b.cache.(Locker)
+	if !ok || !b.Allow() {
+		return false, nil
+	}
+
+	locked, err := l.Locked(key)
+	b.done(err)
+	return locked, err
+}
+
+// Stats : hit and miss counters of the cache, if the cache counts them
+func (b *Breaker) Stats() map[string]Stats {
+	if s, ok := b.cache.(interface{ Stats() map[string]Stats }); ok {
+		return s.Stats()
+	}
+	return map[string]Stats{}
+}
+
+// Record call result, miss and decode error are not failures
+func (b *Breaker) done(err error) {
+	if _, ok := err.(*DecodeError); ok || err == ErrMiss {
+		err = nil
+	}
+
+	if err != nil {
+		b.Failure(err)
+		return
+	}
+	b.Success()
+
+	// Delete values which are changed while the cache was not available
+	keys, tags := b.takePending()
+	if len(keys) > 0 {
+		if err := b.cache.Delete(keys...); err != nil {
+			b.addPending(keys, nil)
+		}
+	}
+	if len(tags) > 0 {
+		if err := b.cache.Invalidate(tags...); err != nil {
+			b.addPending(nil, tags)
+		}
+	}
+}
+
+func (b *Breaker) addPending(keys, tags []string) {
+	b.mu.Lock()
+	for _, key := range keys {
+		b.pendingKeys[key] = struct{}{}
+	}
+	for _, tag := range tags {
+		b.pendingTags[tag] = struct{}{}
+	}
+	b.mu.Unlock()
+}
+
+// Take pending keys and tags
+func (b *Breaker) takePending() (keys, tags []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for key := range b.pendingKeys {
+		keys = append(keys, key)
+	}
+	for tag := range b.pendingTags {
+		tags = append(tags, tag)
+	}
+
+	if len(keys) > 0 || len(tags) > 0 {
+		b.pendingKeys = map[string]struct{}{}
+		b.pendingTags = map[string]struct{}{}
+	}
+
+	return keys, tags
+}
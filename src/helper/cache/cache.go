@@ -0,0 +1,96 @@
+// Package cache contains cache interface with redis and in-memory implementations
+package cache
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrMiss is returned when key does not exist or expired
+var ErrMiss = fmt.Errorf("cache miss")
+
+// Cache stores values with per key ttl, values are encoded by codec (JSON by default).
+// Zero ttl means the value never expires.
+// Value can be tagged, so related values are deleted together without scanning the keys
+type Cache interface {
+	// Get value of the key and decode it into data, returns ErrMiss if not found
+	Get(key string, data interface{}) error
+	// Set value of the key and add the key to the tags
+	Set(key string, data interface{}, ttl time.Duration, tags ...string) error
+	// Delete keys, keys which do not exist are ignored
+	Delete(keys ...string) error
+	// Invalidate delete all keys of the tags
+	Invalidate(tags ...string) error
+	// GetMulti get values of the keys, keys which are not found are not in the result
+	GetMulti(keys []string) (Values, error)
+}
+
+// Locker is implemented by cache which supports locking,
+// used for making sure only one process loads a key
+type Locker interface {
+	// TryLock acquire lock of the key, returns false if the lock is held by other.
+	// Lock is released by unlock or after ttl
+	TryLock(key string, ttl time.Duration) (unlock func(), ok bool, err error)
+	// Locked check if the lock of the key is held
+	Locked(key string) (bool, error)
+}
+
+// Values is encoded values by key
+type Values map[string][]byte
+
+// Decode : decode value of the key into data, returns ErrMiss if not found
+func (v Values) Decode(key string, data interface{}) error {
+	b, ok := v[key]
+	if !ok {
+		return ErrMiss
+	}
+
+	return decode(key, b, data)
+}
+
+// Encode data using current codec, raw value is already encoded
+func encode(key string, data interface{}) ([]byte, error) {
+	if data == nil {
+		return nil, fmt.Errorf("data cannot be nil")
+	}
+
+	if r, ok := data.(rawValue); ok {
+		return r, nil
+	}
+
+	b, err := codec.Encode(data)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling key %s: %v", key, err)
+	}
+	return b, nil
+}
+
+// DecodeError is returned when cached value cannot be decoded,
+// it is not a cache availability error
+type DecodeError struct {
+	Key string
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("error decoding key %s: %v", e.Key, e.Err)
+}
+
+func decode(key string, b []byte, data interface{}) error {
+	if data == nil {
+		return fmt.Errorf("data cannot be nil")
+	}
+
+	if r, ok := data.(*rawValue); ok {
+		*r = append((*r)[0:0], b...)
+		return nil
+	}
+
+	if err := Decode(b, data); err != nil {
+		return &DecodeError{Key: key, Err: err}
+	}
+	return nil
+}
+
+// rawValue is encoded value, it is stored and read as is (e.g. copied between cache tiers)
+type rawValue []byte
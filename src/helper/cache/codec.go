@@ -0,0 +1,199 @@
+// Package cache contains cache interface with redis and in-memory implementations
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/vmihailenco/msgpack"
+)
+
+// Encoded value starts with header: marker, format and compression, followed by the payload.
+// JSON never starts with zero byte, so values without header are JSON (stored by older version).
+// Values are decoded by their header, codec can be changed without flushing the cache
+const (
+	codecMarker     byte = 0
+	codecHeaderSize      = 3
+)
+
+// Values smaller than this are not compressed, compression does not save much for small values
+const defaultMinCompressSize = 512
+
+// Format is serialization format of cached values
+type Format byte
+
+// Serialization formats
+const (
+	FormatJSON    Format = 'j'
+	FormatMsgpack Format = 'm'
+)
+
+// Compression is compression algorithm of cached values
+type Compression byte
+
+// Compression algorithms
+const (
+	CompressionNone   Compression = 'n'
+	CompressionGzip   Compression = 'g'
+	CompressionSnappy Compression = 's'
+)
+
+// Codec encodes cached values. Values smaller than MinCompressSize bytes are not compressed
+type Codec struct {
+	Format          Format
+	Compression     Compression
+	MinCompressSize int
+}
+
+// Codec of new values, decoding does not depend on it
+var codec = Codec{Format: FormatJSON, Compression: CompressionNone}
+
+// SetCodec : set codec of new values, must be called before the cache is used
+func SetCodec(c Codec) {
+	codec = c
+}
+
+// ParseCodec : get codec by format (json or msgpack) and compression (none, gzip or snappy) name,
+// empty names are json and none
+func ParseCodec(format, compression string) (c Codec, err error) {
+	switch format {
+	case "", "json":
+		c.Format = FormatJSON
+	case "msgpack":
+		c.Format = FormatMsgpack
+	default:
+		return c, fmt.Errorf("invalid cache format %s", format)
+	}
+
+	switch compression {
+	case "", "none":
+		c.Compression = CompressionNone
+	case "gzip":
+		c.Compression = CompressionGzip
+	case "snappy":
+		c.Compression = CompressionSnappy
+	default:
+		return c, fmt.Errorf("invalid cache compression %s", compression)
+	}
+
+	c.MinCompressSize = defaultMinCompressSize
+
+	return c, nil
+}
+
+// Encode : serialize and compress data, with header
+func (c Codec) Encode(data interface{}) ([]byte, error) {
+	var payload []byte
+	var err error
+
+	switch c.Format {
+	case FormatJSON:
+		payload, err = json.Marshal(data)
+	case FormatMsgpack:
+		var buf bytes.Buffer
+		err = msgpack.NewEncoder(&buf).UseJSONTag(true).Encode(data)
+		payload = buf.Bytes()
+	default:
+		err = fmt.Errorf("invalid format %q", c.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	compression := c.Compression
+	if len(payload) < c.MinCompressSize {
+		compression = CompressionNone
+	}
+
+	b := make([]byte, codecHeaderSize, codecHeaderSize+len(payload))
+	b[0], b[1], b[2] = codecMarker, byte(c.Format), byte(compression)
+
+	switch compression {
+	case CompressionNone, 0:
+		b[2] = byte(CompressionNone)
+		return append(b, payload...), nil
+	case CompressionGzip:
+		return gzipCompress(b, payload)
+	case CompressionSnappy:
+		return append(b, snappy.Encode(nil, payload)...), nil
+	default:
+		return nil, fmt.Errorf("invalid compression %q", compression)
+	}
+}
+
+// Decode : decompress and deserialize value by its header, value without header is JSON
+func Decode(b []byte, data interface{}) error {
+	if len(b) == 0 || b[0] != codecMarker {
+		return json.Unmarshal(b, data)
+	}
+
+	if len(b) < codecHeaderSize {
+		return fmt.Errorf("invalid header")
+	}
+
+	format, compression, payload := Format(b[1]), Compression(b[2]), b[codecHeaderSize:]
+
+	var err error
+	switch compression {
+	case CompressionNone:
+	case CompressionGzip:
+		payload, err = gzipDecompress(payload)
+	case CompressionSnappy:
+		payload, err = snappy.Decode(nil, payload)
+	default:
+		err = fmt.Errorf("invalid compression %q", compression)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatJSON:
+		return json.Unmarshal(payload, data)
+	case FormatMsgpack:
+		return msgpack.NewDecoder(bytes.NewReader(payload)).UseJSONTag(true).Decode(data)
+	default:
+		return fmt.Errorf("invalid format %q", format)
+	}
+}
+
+// gzip writers are reused, creating a writer allocates large buffers
+var gzipWriters = sync.Pool{
+	New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(nil, gzip.BestSpeed)
+		return w
+	},
+}
+
+// Compress payload and append it to b
+func gzipCompress(b, payload []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(b)
+
+	w := gzipWriters.Get().(*gzip.Writer)
+	defer gzipWriters.Put(w)
+
+	w.Reset(buf)
+	if _, err := w.Write(payload); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func gzipDecompress(payload []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return ioutil.ReadAll(r)
+}
@@ -0,0 +1,276 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Cached news list page, same shape as the news list response
+type testNews struct {
+	ID          int        `json:"id"`
+	AuthorID    int        `json:"author_id"`
+	Author      string     `json:"author"`
+	Title       string     `json:"title"`
+	Slug        string     `json:"slug"`
+	Summary     string     `json:"summary"`
+	Body        string     `json:"body"`
+	CategoryID  int        `json:"category_id"`
+	Category    string     `json:"category"`
+	Tags        []string   `json:"tags"`
+	Status      string     `json:"status"`
+	PublishAt   *time.Time `json:"publish_at,omitempty"`
+	Created     time.Time  `json:"created"`
+	Views       int        `json:"views"`
+	UniqueViews int        `json:"unique_views"`
+}
+
+type testFacet struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type testNewsPage struct {
+	News       []testNews `json:"news"`
+	Page       int        `json:"page"`
+	Size       int        `json:"size"`
+	Total      int        `json:"total"`
+	TotalPages int        `json:"total_pages"`
+	Facets     struct {
+		Categories []testFacet `json:"categories"`
+		Tags       []testFacet `json:"tags"`
+	} `json:"facets"`
+	Links struct {
+		Next string `json:"next,omitempty"`
+		Prev string `json:"prev,omitempty"`
+	} `json:"links"`
+}
+
+func newTestNewsPage() testNewsPage {
+	var p testNewsPage
+	p.Page, p.Size, p.Total, p.TotalPages = 2, 10, 125, 13
+	p.Links.Next = "http://localhost:8080/news?page=3&size=10&category=politik"
+	p.Links.Prev = "http://localhost:8080/news?page=1&size=10&category=politik"
+
+	sentences := []string{
+		"Pemerintah mengumumkan rencana pembangunan infrastruktur baru di sejumlah daerah.",
+		"Proyek tersebut diharapkan selesai dalam tiga tahun dan membuka lapangan kerja bagi warga sekitar.",
+		"Anggaran sebesar %d miliar rupiah disiapkan untuk tahap pertama pembangunan jalan dan jembatan.",
+		"Warga menyambut baik rencana itu, namun meminta pemerintah memperhatikan dampak lingkungan.",
+		"Menurut pengamat, pembangunan di luar Jawa akan mendorong pertumbuhan ekonomi sebesar %d persen.",
+		"Kementerian akan melibatkan %d perusahaan daerah dalam pengerjaan proyek tersebut.",
+	}
+	created := time.Date(2020, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 10; i++ {
+		publishAt := created.Add(time.Duration(i) * time.Hour)
+		title := fmt.Sprintf("Rencana pembangunan infrastruktur baru tahap %d", i+1)
+
+		var body []string
+		for j := 0; j < 16; j++ {
+			sentence := sentences[(i+j*5)%len(sentences)]
+			if strings.Contains(sentence, "%d") {
+				sentence = fmt.Sprintf(sentence, (i+1)*(j+3)*7)
+			}
+			body = append(body, sentence)
+		}
+
+		p.News = append(p.News, testNews{
+			ID:          100 + i,
+			AuthorID:    i%3 + 1,
+			Author:      fmt.Sprintf("Penulis %d", i%3+1),
+			Title:       title,
+			Slug:        strings.ReplaceAll(strings.ToLower(title), " ", "-"),
+			Summary:     body[0],
+			Body:        strings.Join(body, " "),
+			CategoryID:  1,
+			Category:    "politik",
+			Tags:        []string{"infrastruktur", "pemerintah", fmt.Sprintf("daerah-%d", i)},
+			Status:      "published",
+			PublishAt:   &publishAt,
+			Created:     created.Add(time.Duration(i) * time.Minute),
+			Views:       1000 * (i + 1),
+			UniqueViews: 700 * (i + 1),
+		})
+	}
+
+	p.Facets.Categories = []testFacet{{"politik", 125}, {"ekonomi", 40}}
+	p.Facets.Tags = []testFacet{{"infrastruktur", 90}, {"pemerintah", 75}, {"daerah-1", 12}}
+
+	return p
+}
+
+// Decoded times may be in local time zone (msgpack)
+func (p *testNewsPage) utc() {
+	for i := range p.News {
+		n := &p.News[i]
+		n.Created = n.Created.UTC()
+		if n.PublishAt != nil {
+			t := n.PublishAt.UTC()
+			n.PublishAt = &t
+		}
+	}
+}
+
+var testFormats = []string{"json", "msgpack"}
+var testCompressions = []string{"none", "gzip", "snappy"}
+
+func testCodec(t testing.TB, format, compression string) Codec {
+	c, err := ParseCodec(format, compression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	page := newTestNewsPage()
+
+	for _, format := range testFormats {
+		for _, compression := range testCompressions {
+			t.Run(format+"/"+compression, func(t *testing.T) {
+				c := testCodec(t, format, compression)
+
+				b, err := c.Encode(page)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if b[0] != codecMarker || Format(b[1]) != c.Format || Compression(b[2]) != c.Compression {
+					t.Fatalf("header = %q; want format %q and compression %q", b[:codecHeaderSize], c.Format, c.Compression)
+				}
+
+				var got testNewsPage
+				if err := Decode(b, &got); err != nil {
+					t.Fatal(err)
+				}
+				got.utc()
+				if !reflect.DeepEqual(got, page) {
+					t.Errorf("decoded page = %+v; want %+v", got, page)
+				}
+			})
+		}
+	}
+}
+
+func TestCodecSmallValueNotCompressed(t *testing.T) {
+	c := testCodec(t, "json", "gzip")
+
+	b, err := c.Encode(map[string]int{"id": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Compression(b[2]) != CompressionNone {
+		t.Errorf("compression of small value = %q; want none", b[2])
+	}
+
+	var got map[string]int
+	if err := Decode(b, &got); err != nil || got["id"] != 1 {
+		t.Errorf("Decode = %v, %v; want id 1", got, err)
+	}
+}
+
+func TestDecodeLegacyJSON(t *testing.T) {
+	page := newTestNewsPage()
+
+	// Values stored before the codec have no header
+	b, err := json.Marshal(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got testNewsPage
+	if err := Decode(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, page) {
+		t.Errorf("decoded legacy page = %+v; want %+v", got, page)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	var got testNewsPage
+	for _, b := range [][]byte{
+		{codecMarker},
+		{codecMarker, 'x', byte(CompressionNone), '{', '}'},
+		{codecMarker, byte(FormatJSON), 'x', '{', '}'},
+		{codecMarker, byte(FormatJSON), byte(CompressionGzip), '{', '}'},
+	} {
+		if err := Decode(b, &got); err == nil {
+			t.Errorf("Decode(%q) returns no error", b)
+		}
+	}
+}
+
+func TestRawValuePassthrough(t *testing.T) {
+	old := codec
+	defer SetCodec(old)
+	SetCodec(testCodec(t, "msgpack", "snappy"))
+
+	b, err := encode("key", newTestNewsPage())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Raw value is read and stored as is, without decoding
+	var raw rawValue
+	if err := decode("key", b, &raw); err != nil {
+		t.Fatal(err)
+	}
+	copied, err := encode("key", raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(copied) != string(b) {
+		t.Error("raw value is changed when it is copied")
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	page := newTestNewsPage()
+
+	for _, format := range testFormats {
+		for _, compression := range testCompressions {
+			b.Run(format+"/"+compression, func(b *testing.B) {
+				c := testCodec(b, format, compression)
+
+				var encoded []byte
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					var err error
+					if encoded, err = c.Encode(page); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(float64(len(encoded)), "bytes")
+			})
+		}
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	page := newTestNewsPage()
+
+	for _, format := range testFormats {
+		for _, compression := range testCompressions {
+			b.Run(format+"/"+compression, func(b *testing.B) {
+				encoded, err := testCodec(b, format, compression).Encode(page)
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					var p testNewsPage
+					if err := Decode(encoded, &p); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(float64(len(encoded)), "bytes")
+			})
+		}
+	}
+}
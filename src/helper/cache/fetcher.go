@@ -0,0 +1,190 @@
+// Package cache contains cache interface with redis and in-memory implementations
+package cache
+
+import (
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Default fetcher settings
+const (
+	defaultLockTTL   = 5 * time.Second
+	lockPollInterval = 50 * time.Millisecond
+)
+
+// LoadFunc loads value of a cache miss (e.g. from database)
+type LoadFunc func() (interface{}, error)
+
+// Uncacheable is loaded value which is returned but not stored (e.g. incomplete result)
+type Uncacheable struct {
+	Value interface{}
+}
+
+// Fetcher gets value from cache, or loads and stores it if not found (read-through),
+// and prevents cache stampede:
+//   - concurrent loads of the same key in a process are coalesced (singleflight)
+//   - only one process loads the key, if cache supports Locker
+//   - stale copy of the value is served while it is refreshed (stale-while-revalidate).
+//     Stale copy has the same tags, so invalidated value is not served
+type Fetcher struct {
+	TTL      time.Duration // fresh value ttl
+	StaleTTL time.Duration // stale copy lives StaleTTL longer than fresh value, zero means no stale copy
+	LockTTL  time.Duration // maximum loading time before other process loads the key
+	OnError  func(err error)
+
+	group singleflight.Group
+}
+
+// Fetch : get value of the key and decode it into data, load it if not found.
+// Cache errors are reported to OnError and handled as cache miss, load error is returned
+func (f *Fetcher) Fetch(c Cache, key string, data interface{}, load LoadFunc, tags ...string) error {
+	values, err := c.GetMulti([]string{key, staleKey(key)})
+	if err != nil {
+		f.report(err)
+	}
+
+	// Fresh value
+	if err := values.Decode(key, data); err == nil {
+		return nil
+	} else if err != ErrMiss {
+		f.report(err)
+	}
+
+	// Stale value, refresh in background
+	if f.StaleTTL > 0 {
+		if err := values.Decode(staleKey(key), data); err == nil {
+			go f.refresh(c, key, load, tags)
+			return nil
+		} else if err != ErrMiss {
+			f.report(err)
+		}
+	}
+
+	b, err, _ := f.group.Do(key, func() (interface{}, error) {
+		return f.load(c, key, load, tags, true)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Joined background refresh, which does not wait for other process
+	if b == nil {
+		if b, err = f.load(c, key, load, tags, true); err != nil {
+			return err
+		}
+	}
+
+	return decode(key, b.([]byte), data)
+}
+
+// Refresh the key if no other process is loading it
+func (f *Fetcher) refresh(c Cache, key string, load LoadFunc, tags []string) {
+	_, err, _ := f.group.Do(key, func() (interface{}, error) {
+		return f.load(c, key, load, tags, false)
+	})
+	if err != nil {
+		f.report(err)
+	}
+}
+
+// Load value of the key and store it. If the key is being loaded by other process,
+// wait for the value if wait is true, or return nil value otherwise
+func (f *Fetcher) load(c Cache, key string, load LoadFunc, tags []string, wait bool) (interface{}, error) {
+	lockTTL := f.LockTTL
+	if lockTTL <= 0 {
+		lockTTL = defaultLockTTL
+	}
+
+	if l, ok := c.(Locker); ok {
+		unlock, locked, err := l.TryLock(lockKey(key), lockTTL)
+		switch {
+		case err != nil:
+			// Load without lock
+			f.report(err)
+		case locked:
+			defer unlock()
+		case !wait:
+			return nil, nil
+		default:
+			if b, ok := f.wait(c, l, key, lockTTL); ok {
+				return b, nil
+			}
+		}
+	}
+
+	v, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	if u, ok := v.(Uncacheable); ok {
+		return encode(key, u.Value)
+	}
+
+	b, err := encode(key, v)
+	if err != nil {
+		return nil, err
+	}
+
+	// Value is stored encoded, so it is not encoded twice
+	raw := rawValue(b)
+	if err := c.Set(key, raw, f.TTL, tags...); err != nil {
+		f.report(err)
+	}
+	if f.StaleTTL > 0 {
+		if err := c.Set(staleKey(key), raw, f.TTL+f.StaleTTL, tags...); err != nil {
+			f.report(err)
+		}
+	}
+
+	return b, nil
+}
+
+// Wait until the value is stored by other process, returns false if timeout
+// or the lock is released without storing the value (e.g. loading failed)
+func (f *Fetcher) wait(c Cache, l Locker, key string, timeout time.Duration) ([]byte, bool) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(lockPollInterval)
+
+		var raw rawValue
+		err := c.Get(key, &raw)
+		if err == nil {
+			return raw, true
+		}
+		if err != ErrMiss {
+			f.report(err)
+			return nil, false
+		}
+
+		locked, err := l.Locked(lockKey(key))
+		if err != nil {
+			f.report(err)
+			return nil, false
+		}
+		if !locked {
+			// Value may be stored just before the lock is released
+			if err := c.Get(key, &raw); err == nil {
+				return raw, true
+			}
+			return nil, false
+		}
+	}
+
+	return nil, false
+}
+
+func (f *Fetcher) report(err error) {
+	if f.OnError != nil {
+		f.OnError(err)
+	}
+}
+
+func staleKey(key string) string {
+	return key + ":stale"
+}
+
+func lockKey(key string) string {
+	return "lock:" + key
+}
@@ -0,0 +1,253 @@
+// Package cache contains cache interface with redis and in-memory implementations
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Memory is cache stored in process memory, values are copied (encoded)
+// so cached value is not changed by the caller.
+// If size is limited, least recently used value is deleted when the cache is full
+type Memory struct {
+	mu       sync.Mutex
+	maxItems int
+	items    map[string]*list.Element       // value is *memoryItem
+	lru      *list.List                     // most recently used in front
+	tags     map[string]map[string]struct{} // tag to keys
+	locks    map[string]time.Time           // lock expiry
+}
+
+type memoryItem struct {
+	key     string
+	value   []byte
+	expires time.Time // zero means never expires
+}
+
+func (i *memoryItem) expired(now time.Time) bool {
+	return !i.expires.IsZero() && now.After(i.expires)
+}
+
+// NewMemory : create in-memory cache, expired values are deleted every cleanup interval.
+// If cleanup interval is zero, expired values are deleted only when they are read.
+// If maxItems is zero, size is not limited
+func NewMemory(cleanupInterval time.Duration, maxItems int) *Memory {
+	c := &Memory{
+		maxItems: maxItems,
+		items:    map[string]*list.Element{},
+		lru:      list.New(),
+		tags:     map[string]map[string]struct{}{},
+		locks:    map[string]time.Time{},
+	}
+
+	if cleanupInterval > 0 {
+		go func() {
+			for range time.Tick(cleanupInterval) {
+				c.deleteExpired()
+			}
+		}()
+	}
+
+	return c
+}
+
+// Get value of the key and decode it into data, returns ErrMiss if not found
+func (c *Memory) Get(key string, data interface{}) error {
+	c.mu.Lock()
+	b, ok := c.get(key, time.Now())
+	c.mu.Unlock()
+
+	if !ok {
+		return ErrMiss
+	}
+
+	return decode(key, b, data)
+}
+
+// get value and mark it as recently used, lock must be held
+func (c *Memory) get(key string, now time.Time) ([]byte, bool) {
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	item := e.Value.(*memoryItem)
+	if item.expired(now) {
+		c.remove(e)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(e)
+	return item.value, true
+}
+
+// Set value of the key and add the key to the tags, zero ttl means the value never expires
+func (c *Memory) Set(key string, data interface{}, ttl time.Duration, tags ...string) error {
+	b, err := encode(key, data)
+	if err != nil {
+		return err
+	}
+
+	item := &memoryItem{key: key, value: b}
+	if ttl > 0 {
+		item.expires = time.Now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		e.Value = item
+		c.lru.MoveToFront(e)
+	} else {
+		c.items[key] = c.lru.PushFront(item)
+	}
+
+	for _, tag := range tags {
+		if c.tags[tag] == nil {
+			c.tags[tag] = map[string]struct{}{}
+		}
+		c.tags[tag][key] = struct{}{}
+	}
+
+	// Delete least recently used values
+	for c.maxItems > 0 && c.lru.Len() > c.maxItems {
+		c.remove(c.lru.Back())
+	}
+
+	return nil
+}
+
+// Delete keys, keys which do not exist are ignored
+func (c *Memory) Delete(keys ...string) error {
+	c.mu.Lock()
+	for _, key := range keys {
+		if e, ok := c.items[key]; ok {
+			c.remove(e)
+		}
+	}
+	c.mu.Unlock()
+
+	return nil
+}
+
+// Invalidate delete all keys of the tags
+func (c *Memory) Invalidate(tags ...string) error {
+	c.mu.Lock()
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			if e, ok := c.items[key]; ok {
+				c.remove(e)
+			}
+		}
+		delete(c.tags, tag)
+	}
+	c.mu.Unlock()
+
+	return nil
+}
+
+// Clear delete all values and tags, locks are kept
+func (c *Memory) Clear() {
+	c.mu.Lock()
+	c.items = map[string]*list.Element{}
+	c.lru.Init()
+	c.tags = map[string]map[string]struct{}{}
+	c.mu.Unlock()
+}
+
+// Len : number of stored values, including expired values which are not deleted yet
+func (c *Memory) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
+
+// GetMulti get values of the keys, keys which are not found are not in the result
+func (c *Memory) GetMulti(keys []string) (Values, error) {
+	now := time.Now()
+
+	values := Values{}
+
+	c.mu.Lock()
+	for _, key := range keys {
+		if b, ok := c.get(key, now); ok {
+			values[key] = b
+		}
+	}
+	c.mu.Unlock()
+
+	return values, nil
+}
+
+// TryLock acquire lock of the key, returns false if the lock is held by other.
+// Lock is released by unlock or after ttl
+func (c *Memory) TryLock(key string, ttl time.Duration) (unlock func(), ok bool, err error) {
+	now := time.Now()
+	expires := now.Add(ttl)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, held := c.locks[key]; held && now.Before(e) {
+		return nil, false, nil
+	}
+	c.locks[key] = expires
+
+	unlock = func() {
+		c.mu.Lock()
+		// Lock may be expired and acquired by other
+		if c.locks[key].Equal(expires) {
+			delete(c.locks, key)
+		}
+		c.mu.Unlock()
+	}
+
+	return unlock, true, nil
+}
+
+// Locked check if the lock of the key is held
+func (c *Memory) Locked(key string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires, held := c.locks[key]
+	return held && time.Now().Before(expires), nil
+}
+
+// Remove value from the list, tags are cleaned up by deleteExpired.
+// Lock must be held
+func (c *Memory) remove(e *list.Element) {
+	c.lru.Remove(e)
+	delete(c.items, e.Value.(*memoryItem).key)
+}
+
+// Delete all expired values and locks, and remove deleted keys from tags
+func (c *Memory) deleteExpired() {
+	now := time.Now()
+
+	c.mu.Lock()
+	for _, e := range c.items {
+		if e.Value.(*memoryItem).expired(now) {
+			c.remove(e)
+		}
+	}
+	for key, expires := range c.locks {
+		if now.After(expires) {
+			delete(c.locks, key)
+		}
+	}
+	for tag, keys := range c.tags {
+		for key := range keys {
+			if _, ok := c.items[key]; !ok {
+				delete(keys, key)
+			}
+		}
+		if len(keys) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+	c.mu.Unlock()
+}
@@ -0,0 +1,204 @@
+// Package cache contains cache interface with redis and in-memory implementations
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	redis_lib "github.com/filiadielias/kmpr-test/src/helper/redis"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// Tag is stored as redis set of keys
+const redisTagPrefix = "cache:tag:"
+
+// setScript : set the value and add the key to tag sets atomically.
+// Tag set must live as long as its longest living key, so the key can be invalidated.
+// KEYS[1] is the key, KEYS[2..] are tag sets, ARGV[1] is the value, ARGV[2] is ttl in milliseconds
+var setScript = redis.NewScript(-1, `
+local ttl = tonumber(ARGV[2])
+if ttl > 0 then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ttl)
+else
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+
+for i = 2, #KEYS do
+	local current = redis.call('PTTL', KEYS[i])
+	redis.call('SADD', KEYS[i], KEYS[1])
+	if ttl == 0 then
+		redis.call('PERSIST', KEYS[i])
+	elseif current == -2 or (current >= 0 and current < ttl) then
+		redis.call('PEXPIRE', KEYS[i], ttl)
+	end
+end
+
+return 1
+`)
+
+// invalidateScript : delete keys of the tag sets and the tag sets, returns number of deleted keys.
+// Keys are deleted in batches, lua unpack has limited stack size
+var invalidateScript = redis.NewScript(-1, `
+local n = 0
+for i = 1, #KEYS do
+	local keys = redis.call('SMEMBERS', KEYS[i])
+	for j = 1, #keys, 1000 do
+		n = n + redis.call('DEL', unpack(keys, j, math.min(j + 999, #keys)))
+	end
+	redis.call('DEL', KEYS[i])
+end
+
+return n
+`)
+
+// Redis is cache stored in redis
+type Redis struct {
+	pool *redis.Pool
+}
+
+// NewRedis : create cache using redis pool
+func NewRedis(pool *redis.Pool) *Redis {
+	return &Redis{pool: pool}
+}
+
+// Get value of the key and decode it into data, returns ErrMiss if not found
+func (c *Redis) Get(key string, data interface{}) error {
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	b, err := redis.Bytes(conn.Do("GET", key))
+	if err == redis.ErrNil {
+		return ErrMiss
+	}
+	if err != nil {
+		return fmt.Errorf("error getting key %s: %v", key, err)
+	}
+
+	return decode(key, b, data)
+}
+
+// Set value of the key and add the key to the tags, zero ttl means the value never expires
+func (c *Redis) Set(key string, data interface{}, ttl time.Duration, tags ...string) error {
+	b, err := encode(key, data)
+	if err != nil {
+		return err
+	}
+
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	// Expire in milliseconds, so ttl below one second is allowed
+	px := int64(0)
+	if ttl > 0 {
+		px = int64(ttl / time.Millisecond)
+	}
+
+	if len(tags) == 0 {
+		args := redis.Args{}.Add(key, b)
+		if px > 0 {
+			args = args.Add("PX", px)
+		}
+		_, err = conn.Do("SET", args...)
+	} else {
+		args := redis.Args{}.Add(len(tags)+1, key).AddFlat(tagKeys(tags)).Add(b, px)
+		_, err = setScript.Do(conn, args...)
+	}
+	if err != nil {
+		return fmt.Errorf("error setting key %s: %v", key, err)
+	}
+
+	return nil
+}
+
+// Delete keys, keys which do not exist are ignored
+func (c *Redis) Delete(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	if _, err := conn.Do("DEL", redis.Args{}.AddFlat(keys)...); err != nil {
+		return fmt.Errorf("error deleting keys %v: %v", keys, err)
+	}
+	return nil
+}
+
+// Invalidate delete all keys of the tags, only keys of the tags are read (no keyspace scan)
+func (c *Redis) Invalidate(tags ...string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	args := redis.Args{}.Add(len(tags)).AddFlat(tagKeys(tags))
+	if _, err := invalidateScript.Do(conn, args...); err != nil {
+		return fmt.Errorf("error invalidating tags %v: %v", tags, err)
+	}
+	return nil
+}
+
+// GetMulti get values of the keys in one request, keys which are not found are not in the result
+func (c *Redis) GetMulti(keys []string) (Values, error) {
+	values := Values{}
+	if len(keys) == 0 {
+		return values, nil
+	}
+
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	bs, err := redis.ByteSlices(conn.Do("MGET", redis.Args{}.AddFlat(keys)...))
+	if err != nil {
+		return values, fmt.Errorf("error getting keys %v: %v", keys, err)
+	}
+
+	for i, b := range bs {
+		// Not found key is nil
+		if b != nil {
+			values[keys[i]] = b
+		}
+	}
+
+	return values, nil
+}
+
+// TryLock acquire lock of the key, returns false if the lock is held by other.
+// Lock is released by unlock or after ttl
+func (c *Redis) TryLock(key string, ttl time.Duration) (unlock func(), ok bool, err error) {
+	l := redis_lib.NewLock(key, ttl, c.pool)
+	if ok, err := l.TryLock(); !ok {
+		return nil, false, err
+	}
+
+	unlock = func() {
+		l.Unlock()
+	}
+
+	return unlock, true, nil
+}
+
+// Locked check if the lock of the key is held
+func (c *Redis) Locked(key string) (bool, error) {
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	ok, err := redis.Bool(conn.Do("EXISTS", key))
+	if err != nil {
+		return ok, fmt.Errorf("error checking lock %s: %v", key, err)
+	}
+	return ok, nil
+}
+
+func tagKeys(tags []string) []string {
+	keys := make([]string, len(tags))
+	for i, tag := range tags {
+		keys[i] = redisTagPrefix + tag
+	}
+
+	return keys
+}
@@ -0,0 +1,284 @@
+// Package cache contains cache interface with redis and in-memory implementations
+package cache
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// Redis pub/sub channel of local cache invalidation messages
+const invalidateChannel = "cache:invalidate"
+
+// Wait before subscribing again after subscription error
+const resubscribeDelay = time.Second
+
+// Subscription connection is checked every health check interval
+const pubsubHealthCheck = 30 * time.Second
+
+// Stats is hit and miss counters of a cache tier
+type Stats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+}
+
+type counter struct {
+	hits   uint64
+	misses uint64
+}
+
+func (c *counter) add(hit bool) {
+	if hit {
+		atomic.AddUint64(&c.hits, 1)
+	} else {
+		atomic.AddUint64(&c.misses, 1)
+	}
+}
+
+func (c *counter) stats() Stats {
+	return Stats{
+		Hits:   atomic.LoadUint64(&c.hits),
+		Misses: atomic.LoadUint64(&c.misses),
+	}
+}
+
+// invalidateMessage is published when a value is changed,
+// so other instances delete the value from their local cache
+type invalidateMessage struct {
+	Source string   `json:"source"` // instance which changes the value
+	Keys   []string `json:"keys,omitempty"`
+	Tags   bool     `json:"tags,omitempty"` // tags are invalidated
+}
+
+// Tiered is two tier cache: in-process LRU cache in front of redis.
+// Local values live shortly, and are deleted when other instances change them (redis pub/sub).
+// Local values which are read from redis do not know their tags,
+// so tag invalidation clears the whole local cache
+type Tiered struct {
+	local    *Memory
+	remote   *Redis
+	localTTL time.Duration
+	id       string // instance id, own invalidation messages are ignored
+
+	localStats  counter
+	remoteStats counter
+}
+
+// NewTiered : create two tier cache, local cache stores at most maxItems values for localTTL.
+// Invalidation messages are received in background
+func NewTiered(remote *Redis, maxItems int, localTTL time.Duration) *Tiered {
+	c := &Tiered{
+		local:    NewMemory(localTTL, maxItems),
+		remote:   remote,
+		localTTL: localTTL,
+		id:       instanceID(),
+	}
+
+	go c.subscribe()
+
+	return c
+}
+
+// Random instance id, use start time if random number is not available
+func instanceID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Get value of the key from local cache, or from redis if not found locally.
+// Returns ErrMiss if not found
+func (c *Tiered) Get(key string, data interface{}) error {
+	err := c.local.Get(key, data)
+	c.localStats.add(err == nil)
+	if err != ErrMiss {
+		return err
+	}
+
+	var raw rawValue
+	err = c.remote.Get(key, &raw)
+	c.remoteStats.add(err == nil)
+	if err != nil {
+		return err
+	}
+
+	c.local.Set(key, raw, c.localTTL)
+
+	return decode(key, raw, data)
+}
+
+// Set value of the key in both tiers, other instances delete their local value
+func (c *Tiered) Set(key string, data interface{}, ttl time.Duration, tags ...string) error {
+	if err := c.remote.Set(key, data, ttl, tags...); err != nil {
+		return err
+	}
+
+	c.local.Set(key, data, c.ttl(ttl), tags...)
+
+	return c.publish(invalidateMessage{Keys: []string{key}})
+}
+
+// Delete keys from both tiers
+func (c *Tiered) Delete(keys ...string) error {
+	if err := c.remote.Delete(keys...); err != nil {
+		return err
+	}
+
+	c.local.Delete(keys...)
+
+	return c.publish(invalidateMessage{Keys: keys})
+}
+
+// Invalidate delete all keys of the tags, local cache of every instance is cleared
+func (c *Tiered) Invalidate(tags ...string) error {
+	if err := c.remote.Invalidate(tags...); err != nil {
+		return err
+	}
+
+	c.local.Clear()
+
+	return c.publish(invalidateMessage{Tags: true})
+}
+
+// GetMulti get values of the keys, keys which are not found locally are read from redis
+func (c *Tiered) GetMulti(keys []string) (Values, error) {
+	values, _ := c.local.GetMulti(keys)
+
+	var missing []string
+	for _, key := range keys {
+		_, ok := values[key]
+		c.localStats.add(ok)
+		if !ok {
+			missing = append(missing, key)
+		}
+	}
+
+	if len(missing) == 0 {
+		return values, nil
+	}
+
+	remote, err := c.remote.GetMulti(missing)
+	if err != nil {
+		return values, err
+	}
+
+	for _, key := range missing {
+		b, ok := remote[key]
+		c.remoteStats.add(ok)
+		if ok {
+			values[key] = b
+			c.local.Set(key, rawValue(b), c.localTTL)
+		}
+	}
+
+	return values, nil
+}
+
+// TryLock acquire lock of the key in redis
+func (c *Tiered) TryLock(key string, ttl time.Duration) (unlock func(), ok bool, err error) {
+	return c.remote.TryLock(key, ttl)
+}
+
+// Locked check if the lock of the key is held in redis
+func (c *Tiered) Locked(key string) (bool, error) {
+	return c.remote.Locked(key)
+}
+
+// Stats : hit and miss counters by tier (local and redis)
+func (c *Tiered) Stats() map[string]Stats {
+	return map[string]Stats{
+		"local": c.localStats.stats(),
+		"redis": c.remoteStats.stats(),
+	}
+}
+
+// Local value ttl must not be longer than redis value ttl
+func (c *Tiered) ttl(ttl time.Duration) time.Duration {
+	if ttl > 0 && ttl < c.localTTL {
+		return ttl
+	}
+	return c.localTTL
+}
+
+func (c *Tiered) publish(m invalidateMessage) error {
+	m.Source = c.id
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	conn := c.remote.pool.Get()
+	defer conn.Close()
+
+	if _, err := conn.Do("PUBLISH", invalidateChannel, b); err != nil {
+		return fmt.Errorf("error publishing cache invalidation: %v", err)
+	}
+	return nil
+}
+
+// Receive invalidation messages from other instances, subscribe again if connection lost
+func (c *Tiered) subscribe() {
+	for {
+		c.receive()
+
+		// Messages may be lost while not subscribed
+		c.local.Clear()
+		time.Sleep(resubscribeDelay)
+	}
+}
+
+func (c *Tiered) receive() {
+	psc := redis.PubSubConn{Conn: c.remote.pool.Get()}
+	defer psc.Close()
+
+	if err := psc.Subscribe(invalidateChannel); err != nil {
+		return
+	}
+
+	// Ping periodically, so read timeout is not reached when there is no message
+	// and lost connection is detected
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(pubsubHealthCheck)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := psc.Ping(""); err != nil {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for {
+		switch v := psc.ReceiveWithTimeout(2 * pubsubHealthCheck).(type) {
+		case redis.Message:
+			var m invalidateMessage
+			if err := json.Unmarshal(v.Data, &m); err != nil || m.Source == c.id {
+				continue
+			}
+
+			if m.Tags {
+				c.local.Clear()
+			} else {
+				c.local.Delete(m.Keys...)
+			}
+		case error:
+			return
+		}
+	}
+}
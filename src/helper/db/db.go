@@ -3,6 +3,7 @@ package db
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/jmoiron/sqlx"
@@ -83,7 +84,16 @@ type QueryBuilder struct {
 		Column   string
 		Value    interface{}
 		Operator string
+		Subquery string
 	}
+	// keyset (cursor) condition, e.g. (created, id) < ($1, $2)
+	keyset struct {
+		Columns  []string
+		Values   []interface{}
+		Operator string
+	}
+	// ordered sort, used when sort order matters (e.g. keyset pagination)
+	sorts []string
 	Page  int
 	Limit int
 	Sort  map[string]string
@@ -105,10 +115,49 @@ func (qb *QueryBuilder) AddFilter(column string, value interface{}, operator str
 		Column   string
 		Value    interface{}
 		Operator string
+		Subquery string
+	}{
+		column,
+		value,
+		operator,
+		"",
+	}
+
+	qb.filters = append(qb.filters, data)
+
+	return nil
+}
+
+// AddSubqueryFilter used for adding filter condition using subquery, use ? as the value placeholder.
+// Example : AddSubqueryFilter("id", "in", "select news_id from news_tags where tag_id = ?", 1)
+// will produce id in (select news_id from news_tags where tag_id = $1)
+func (qb *QueryBuilder) AddSubqueryFilter(column, operator, subquery string, value interface{}) error {
+	//validate empty column and subquery
+	if len(column) == 0 || len(subquery) == 0 {
+		return fmt.Errorf("Column and subquery cannot be empty")
+	}
+
+	//subquery returns one or more rows
+	switch operator {
+	case "in", "not in", "=", "!=":
+	default:
+		return fmt.Errorf("Invalid subquery operator %s", operator)
+	}
+
+	if strings.Count(subquery, "?") != 1 {
+		return fmt.Errorf("Subquery must have exactly one value placeholder")
+	}
+
+	data := struct {
+		Column   string
+		Value    interface{}
+		Operator string
+		Subquery string
 	}{
 		column,
 		value,
 		operator,
+		subquery,
 	}
 
 	qb.filters = append(qb.filters, data)
@@ -116,6 +165,44 @@ func (qb *QueryBuilder) AddFilter(column string, value interface{}, operator str
 	return nil
 }
 
+// SetKeyset used for keyset (cursor) pagination, compare multiple columns as a row value.
+// Example : SetKeyset([]string{"created", "id"}, []interface{}{t, 10}, "<")
+// will produce (created, id) < ($1, $2)
+func (qb *QueryBuilder) SetKeyset(columns []string, values []interface{}, operator string) error {
+	if len(columns) == 0 || len(columns) != len(values) {
+		return fmt.Errorf("Columns and values must have the same length")
+	}
+
+	// Only comparison operators are allowed for row values
+	switch operator {
+	case "<", ">", "<=", ">=":
+	default:
+		return fmt.Errorf("Invalid keyset operator %s", operator)
+	}
+
+	qb.keyset.Columns = columns
+	qb.keyset.Values = values
+	qb.keyset.Operator = operator
+
+	return nil
+}
+
+// AddSort used for adding ordered sort, sorts added here are written before Sort map
+func (qb *QueryBuilder) AddSort(column, direction string) error {
+	if len(column) == 0 {
+		return fmt.Errorf("Column cannot be empty")
+	}
+
+	direction = strings.ToLower(direction)
+	if direction != "asc" && direction != "desc" {
+		return fmt.Errorf("Invalid sort direction %s", direction)
+	}
+
+	qb.sorts = append(qb.sorts, fmt.Sprintf("%s %s", column, direction))
+
+	return nil
+}
+
 // GetQuery : construct query
 func (qb *QueryBuilder) GetQuery() (string, []interface{}) {
 	var query strings.Builder
@@ -125,11 +212,14 @@ func (qb *QueryBuilder) GetQuery() (string, []interface{}) {
 
 	var filterValues []interface{}
 
+	count := 1
 	if len(qb.filters) > 0 {
-		count := 1
 		for _, flt := range qb.filters {
 			query.WriteString(" AND ")
 			switch {
+			case len(flt.Subquery) > 0:
+				subquery := strings.Replace(flt.Subquery, "?", fmt.Sprintf("$%d", count), 1)
+				query.WriteString(fmt.Sprintf(" %s %s (%s) ", flt.Column, flt.Operator, subquery))
 			case flt.Operator == "is not null" || flt.Operator == "is null":
 				query.WriteString(fmt.Sprintf(" %s %s ", flt.Column, flt.Operator))
 				continue //doesn't add param value
@@ -144,13 +234,38 @@ func (qb *QueryBuilder) GetQuery() (string, []interface{}) {
 		}
 	}
 
+	if len(qb.keyset.Columns) > 0 {
+		params := make([]string, len(qb.keyset.Values))
+		for i, v := range qb.keyset.Values {
+			params[i] = fmt.Sprintf("$%d", count)
+			filterValues = append(filterValues, v)
+			count++
+		}
+
+		query.WriteString(fmt.Sprintf(" AND (%s) %s (%s) ",
+			strings.Join(qb.keyset.Columns, ", "), qb.keyset.Operator, strings.Join(params, ", ")))
+	}
+
+	sorts := append([]string{}, qb.sorts...)
 	if len(qb.Sort) > 0 {
-		query.WriteString(" ORDER BY ")
-		for key, value := range qb.Sort {
-			query.WriteString(fmt.Sprintf(" %s %s ", key, value))
+		// Sort map keys, so the query is always the same
+		keys := make([]string, 0, len(qb.Sort))
+		for key := range qb.Sort {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			sorts = append(sorts, fmt.Sprintf("%s %s", key, qb.Sort[key]))
 		}
 	}
 
+	if len(sorts) > 0 {
+		query.WriteString(" ORDER BY ")
+		query.WriteString(strings.Join(sorts, ", "))
+		query.WriteString(" ")
+	}
+
 	if qb.Limit > 0 {
 		query.WriteString(fmt.Sprintf(" LIMIT %d ", qb.Limit))
 
@@ -164,6 +279,18 @@ func (qb *QueryBuilder) GetQuery() (string, []interface{}) {
 	return query.String(), filterValues
 }
 
+// GetCountQuery : construct query without sort and pagination, used for counting rows.
+// Example : Query = "select count(*) from news"
+func (qb *QueryBuilder) GetCountQuery() (string, []interface{}) {
+	c := *qb
+	c.sorts = nil
+	c.Sort = nil
+	c.Page = 0
+	c.Limit = 0
+
+	return c.GetQuery()
+}
+
 // Check filter operator
 func isValidOperator(operator string) bool {
 
@@ -6,8 +6,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"strconv"
-	"strings"
 
 	"github.com/elastic/go-elasticsearch"
 	"github.com/elastic/go-elasticsearch/esapi"
@@ -45,6 +45,57 @@ func newClient(host string, port int) (*elasticsearch.Client, error) {
 	return es, nil
 }
 
+// CreateIndex : create index with specified settings and mappings,
+// do nothing if index already exists
+func CreateIndex(es *elasticsearch.Client, index string, body io.Reader) error {
+	if len(index) == 0 {
+		return ErrInvalidIndex
+	}
+
+	res, err := es.Indices.Exists([]string{index})
+	if err != nil {
+		return transportError(err)
+	}
+	res.Body.Close()
+
+	// Index already exists
+	if res.StatusCode == 200 {
+		return nil
+	}
+
+	res, err = es.Indices.Create(index, es.Indices.Create.WithBody(body))
+	if err != nil {
+		return transportError(err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return responseError(res)
+	}
+
+	return nil
+}
+
+// PutMapping : add new fields to index mapping, existing fields cannot be changed.
+// body is mapping (e.g. {"properties": {...}}), without "mappings" key
+func PutMapping(es *elasticsearch.Client, index string, body io.Reader) error {
+	if len(index) == 0 {
+		return ErrInvalidIndex
+	}
+
+	res, err := es.Indices.PutMapping(body, es.Indices.PutMapping.WithIndex(index))
+	if err != nil {
+		return transportError(err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return responseError(res)
+	}
+
+	return nil
+}
+
 // AddDocument for adding document to specified index
 func AddDocument(es *elasticsearch.Client, index, id string, data interface{}) error {
 	if data == nil {
@@ -77,27 +128,73 @@ func AddDocument(es *elasticsearch.Client, index, id string, data interface{}) e
 	// Perform the request
 	res, err := req.Do(context.Background(), es)
 	if err != nil {
-		return fmt.Errorf("error getting response: %s", err)
+		return transportError(err)
 	}
 	defer res.Body.Close()
 
 	// Check if
 	if res.IsError() {
-		return fmt.Errorf("[%s] error indexing document ID=%s", res.Status(), id)
+		return responseError(res)
 	}
 
-	var r map[string]interface{}
+	var r writeResponse
 	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
 		return fmt.Errorf("[%s] error parsing the response body: %s", res.Status(), err)
 	}
 
-	results, ok := r["_shards"].(map[string]interface{})
-	if !ok {
-		return fmt.Errorf("insert failed")
+	if r.Shards.Successful <= 0 {
+		return fmt.Errorf("insert failed: document ID=%s is not stored in any shard", id)
+	}
+
+	return nil
+}
+
+// writeResponse is index and delete document response
+type writeResponse struct {
+	Index   string `json:"_index"`
+	ID      string `json:"_id"`
+	Version int    `json:"_version"`
+	Result  string `json:"result"`
+	Shards  struct {
+		Total      int `json:"total"`
+		Successful int `json:"successful"`
+		Failed     int `json:"failed"`
+	} `json:"_shards"`
+}
+
+// DeleteDocument for deleting document from specified index,
+// document that does not exist is not an error
+func DeleteDocument(es *elasticsearch.Client, index, id string) error {
+	// Index cannot be empty
+	if len(index) == 0 {
+		return ErrInvalidIndex
+	}
+
+	if len(id) == 0 {
+		return ErrInvalidID
 	}
 
-	if results["successful"].(float64) <= 0 {
-		return fmt.Errorf("insert failed")
+	// Set up the request object
+	req := esapi.DeleteRequest{
+		Index:      index,
+		DocumentID: id,
+		Refresh:    "true",
+	}
+
+	// Perform the request
+	res, err := req.Do(context.Background(), es)
+	if err != nil {
+		return transportError(err)
+	}
+	defer res.Body.Close()
+
+	if !res.IsError() {
+		return nil
+	}
+
+	// Document not found is not an error, but index not found is
+	if err := responseError(res); res.StatusCode != 404 || IsIndexNotFound(err) {
+		return err
 	}
 
 	return nil
@@ -108,13 +205,95 @@ type Query struct {
 	// If page is zero, get all data
 	Page int `json:"from,omitempty"`
 	// If limit is zero, get all data
-	Limit int               `json:"size,omitempty"`
-	Sort  map[string]string `json:"sort"`
+	Limit int `json:"size,omitempty"`
+	// Sort is a list, so tie breaker field can be added (e.g. created, then id)
+	Sort []map[string]string `json:"sort,omitempty"`
+	// SearchAfter is sort values of the last document from previous page (keyset pagination).
+	// If SearchAfter is set, Page is ignored
+	SearchAfter []interface{} `json:"search_after,omitempty"`
+	// Query is built from filters and must clauses when GetJSON called
+	Query map[string]interface{} `json:"query,omitempty"`
+	// Aggs is named aggregations, e.g. {"tags": {"terms": {"field": "tags"}}}
+	Aggs map[string]interface{} `json:"aggs,omitempty"`
+	// Highlight is highlight settings, e.g. {"fields": {"title": {}}}
+	Highlight map[string]interface{} `json:"highlight,omitempty"`
+	// Suggest is named suggesters, e.g. {"title": {"prefix": "ele", "completion": {"field": "suggest"}}}
+	Suggest map[string]interface{} `json:"suggest,omitempty"`
+	// SkipHits : documents are not returned (size is zero), used for facets, stats and suggestions
+	SkipHits bool `json:"-"`
+	filters  []map[string]interface{}
+	musts    []map[string]interface{}
+}
+
+// AddAggregation used for adding named aggregation, e.g. AddAggregation("tags", "terms", {"field": "tags"})
+func (q *Query) AddAggregation(name, aggType string, params map[string]interface{}) {
+	if q.Aggs == nil {
+		q.Aggs = map[string]interface{}{}
+	}
+
+	q.Aggs[name] = map[string]interface{}{
+		aggType: params,
+	}
+}
+
+// AddTermsAggregation used for adding terms aggregation (facet) of the field
+func (q *Query) AddTermsAggregation(name, field string, size int) {
+	q.AddAggregation(name, "terms", map[string]interface{}{
+		"field": field,
+		"size":  size,
+	})
+}
+
+// AddDateHistogramAggregation used for adding date histogram aggregation,
+// interval is calendar interval (e.g. 1d, 1M)
+func (q *Query) AddDateHistogramAggregation(name, field, interval string) {
+	q.AddAggregation(name, "date_histogram", map[string]interface{}{
+		"field":             field,
+		"calendar_interval": interval,
+	})
+}
+
+// AddCardinalityAggregation used for adding cardinality (distinct count) aggregation
+func (q *Query) AddCardinalityAggregation(name, field string) {
+	q.AddAggregation(name, "cardinality", map[string]interface{}{
+		"field": field,
+	})
+}
+
+// AddCompletionSuggest used for adding completion suggester, duplicate suggestions are skipped
+func (q *Query) AddCompletionSuggest(name, field, prefix string, size int) {
+	if q.Suggest == nil {
+		q.Suggest = map[string]interface{}{}
+	}
+
+	q.Suggest[name] = map[string]interface{}{
+		"prefix": prefix,
+		"completion": map[string]interface{}{
+			"field":           field,
+			"size":            size,
+			"skip_duplicates": true,
+		},
+	}
+}
+
+// AddMust used for adding scoring query clause (match, more_like_this, etc), all clauses must match.
+// Unlike filter, must clause affects document score
+func (q *Query) AddMust(clause map[string]interface{}) {
+	q.musts = append(q.musts, clause)
+}
+
+// AddFilter used for adding filter clause (term, range, etc), all filters must match.
+// Example : AddFilter(map[string]interface{}{"term": map[string]interface{}{"author": "x"}})
+func (q *Query) AddFilter(clause map[string]interface{}) {
+	q.filters = append(q.filters, clause)
 }
 
 // GetJSON : build Elasticsearch search query
 func (q *Query) GetJSON() (string, error) {
-	if q.Limit > 0 {
+	if len(q.SearchAfter) > 0 || q.SkipHits {
+		// search_after cannot be combined with from
+		q.Page = 0
+	} else if q.Limit > 0 {
 		if q.Page > 0 {
 			q.Page--
 
@@ -123,57 +302,56 @@ func (q *Query) GetJSON() (string, error) {
 		}
 	}
 
-	b, err := json.Marshal(q)
-	if err != nil {
-		return "", err
+	if len(q.filters) > 0 || len(q.musts) > 0 {
+		clauses := map[string]interface{}{}
+		if len(q.filters) > 0 {
+			clauses["filter"] = q.filters
+		}
+		if len(q.musts) > 0 {
+			clauses["must"] = q.musts
+		}
+
+		q.Query = map[string]interface{}{
+			"bool": clauses,
+		}
 	}
 
-	return string(b), nil
-}
+	// Override size, because zero size is omitted
+	type query Query
+	body := struct {
+		*query
+		Size *int `json:"size,omitempty"`
+	}{query: (*query)(q)}
 
-// GetDocuments : get elasticsearch document from specified index
-func GetDocuments(es *elasticsearch.Client, index string, q *Query) (ids []int, err error) {
-	if len(index) == 0 {
-		return ids, ErrInvalidIndex
+	size := q.Limit
+	if q.SkipHits {
+		size = 0
 	}
-
-	s, err := q.GetJSON()
-	if err != nil {
-		return ids, err
+	if size > 0 || q.SkipHits {
+		body.Size = &size
 	}
 
-	res, err := es.Search(
-		es.Search.WithContext(context.Background()),
-		es.Search.WithIndex(index),
-		es.Search.WithBody(strings.NewReader(s)),
-		es.Search.WithTrackTotalHits(true),
-		es.Search.WithPretty(),
-	)
+	b, err := json.Marshal(body)
 	if err != nil {
-		return ids, fmt.Errorf("error getting response: %s", err)
+		return "", err
 	}
-	defer res.Body.Close()
 
-	var r map[string]interface{}
-	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
-		return ids, fmt.Errorf("error parsing the response body: %s", err)
-	}
+	return string(b), nil
+}
 
-	if res.IsError() {
-		// Print the response status and error information.
-		return ids, fmt.Errorf("[%s] %s: %s",
-			res.Status(),
-			r["error"].(map[string]interface{})["type"],
-			r["error"].(map[string]interface{})["reason"],
-		)
+// GetDocuments : get elasticsearch document from specified index,
+// returns document ids and total hits
+func GetDocuments(es *elasticsearch.Client, index string, q *Query) (ids []int, total int, err error) {
+	r, err := Search(es, index, q)
+	if err != nil {
+		return ids, total, err
 	}
 
-	// Print the ID and document source for each hit.
-	for _, hit := range r["hits"].(map[string]interface{})["hits"].([]interface{}) {
-		id, _ := strconv.Atoi(hit.(map[string]interface{})["_id"].(string))
+	for _, hit := range r.Hits {
+		id, _ := strconv.Atoi(hit.ID)
 
 		ids = append(ids, id)
 	}
 
-	return ids, nil
+	return ids, r.Total, nil
 }
@@ -0,0 +1,117 @@
+// Package elastic contains helper functions for operating with elasticsearch
+package elastic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+
+	"github.com/elastic/go-elasticsearch/esapi"
+)
+
+// Error is elasticsearch error response
+type Error struct {
+	Status int    `json:"status"`
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+// Sentinel errors, use errors.Is or IsIndexNotFound, IsVersionConflict and IsTimeout to check
+var (
+	ErrIndexNotFound   = &Error{Type: "index_not_found_exception"}
+	ErrVersionConflict = &Error{Type: "version_conflict_engine_exception"}
+	ErrTimeout         = &Error{Type: "timeout"}
+)
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("[%d] %s: %s", e.Status, e.Type, e.Reason)
+}
+
+// Is : compare error by type, any timeout type (e.g. timeout_exception) or
+// timeout status (408, 504) is ErrTimeout
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+
+	if t.Type == ErrTimeout.Type {
+		return strings.Contains(e.Type, "timeout") || e.Status == 408 || e.Status == 504
+	}
+
+	return t.Type == e.Type
+}
+
+// IsIndexNotFound : check if error is caused by index does not exist
+func IsIndexNotFound(err error) bool {
+	return is(err, ErrIndexNotFound)
+}
+
+// IsVersionConflict : check if error is caused by document version conflict
+func IsVersionConflict(err error) bool {
+	return is(err, ErrVersionConflict)
+}
+
+// IsTimeout : check if error is caused by request or search timeout
+func IsTimeout(err error) bool {
+	return is(err, ErrTimeout)
+}
+
+// Check if err or error wrapped by err is target
+func is(err error, target *Error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Is(target)
+}
+
+// Parse error response body, response body is not closed
+func responseError(res *esapi.Response) error {
+	e := &Error{Status: res.StatusCode, Type: "unknown", Reason: res.Status()}
+
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil || len(b) == 0 {
+		return e
+	}
+
+	// Error can be an object or a string
+	var r struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(b, &r); err != nil || len(r.Error) == 0 {
+		return e
+	}
+
+	var detail struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal(r.Error, &detail); err == nil {
+		e.Type = detail.Type
+		e.Reason = detail.Reason
+		return e
+	}
+
+	var reason string
+	if err := json.Unmarshal(r.Error, &reason); err == nil {
+		e.Reason = reason
+	}
+
+	return e
+}
+
+// Wrap transport error (elasticsearch is not reachable), timeout is converted to ErrTimeout type
+func transportError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &Error{Type: ErrTimeout.Type, Reason: err.Error()}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return &Error{Type: ErrTimeout.Type, Reason: err.Error()}
+	}
+
+	return fmt.Errorf("error getting response: %s", err)
+}
@@ -0,0 +1,219 @@
+// Package elastic contains helper functions for operating with elasticsearch
+package elastic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/go-elasticsearch"
+)
+
+// SearchResult is elasticsearch search response
+type SearchResult struct {
+	Total        int
+	MaxScore     float64
+	Hits         []Hit
+	Aggregations map[string]json.RawMessage
+	Suggest      map[string][]SuggestEntry
+}
+
+// Hit is a document of search result
+type Hit struct {
+	Index     string              `json:"_index"`
+	ID        string              `json:"_id"`
+	Score     float64             `json:"_score"`
+	Source    json.RawMessage     `json:"_source"`
+	Highlight map[string][]string `json:"highlight"`
+	Sort      []interface{}       `json:"sort"`
+}
+
+// DecodeSource : decode document source into struct
+func (h *Hit) DecodeSource(data interface{}) error {
+	if len(h.Source) == 0 {
+		return fmt.Errorf("document %s has no source", h.ID)
+	}
+
+	return json.Unmarshal(h.Source, data)
+}
+
+// SuggestEntry is suggestions of a suggester input text
+type SuggestEntry struct {
+	Text    string          `json:"text"`
+	Options []SuggestOption `json:"options"`
+}
+
+// SuggestOption is a suggested document, Text is the matched input
+type SuggestOption struct {
+	Text string `json:"text"`
+	Hit
+}
+
+// Suggestions : get suggester options by name
+func (r *SearchResult) Suggestions(name string) []SuggestOption {
+	var opts []SuggestOption
+	for _, e := range r.Suggest[name] {
+		opts = append(opts, e.Options...)
+	}
+
+	return opts
+}
+
+// Bucket is terms aggregation bucket, numeric keys are converted to string
+type Bucket struct {
+	Key      string `json:"key"`
+	DocCount int    `json:"doc_count"`
+}
+
+// UnmarshalJSON : key can be string or number
+func (b *Bucket) UnmarshalJSON(data []byte) error {
+	var r struct {
+		Key      interface{} `json:"key"`
+		DocCount int         `json:"doc_count"`
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return err
+	}
+
+	switch k := r.Key.(type) {
+	case string:
+		b.Key = k
+	case float64:
+		b.Key = strconv.FormatFloat(k, 'f', -1, 64)
+	default:
+		b.Key = fmt.Sprint(k)
+	}
+	b.DocCount = r.DocCount
+
+	return nil
+}
+
+// DateBucket is date histogram aggregation bucket, key is epoch milliseconds
+type DateBucket struct {
+	Key         int64  `json:"key"`
+	KeyAsString string `json:"key_as_string"`
+	DocCount    int    `json:"doc_count"`
+}
+
+// Terms : get terms aggregation buckets by name
+func (r *SearchResult) Terms(name string) ([]Bucket, error) {
+	var agg struct {
+		Buckets []Bucket `json:"buckets"`
+	}
+
+	err := r.aggregation(name, &agg)
+	return agg.Buckets, err
+}
+
+// DateHistogram : get date histogram aggregation buckets by name
+func (r *SearchResult) DateHistogram(name string) ([]DateBucket, error) {
+	var agg struct {
+		Buckets []DateBucket `json:"buckets"`
+	}
+
+	err := r.aggregation(name, &agg)
+	return agg.Buckets, err
+}
+
+// Cardinality : get cardinality aggregation value by name
+func (r *SearchResult) Cardinality(name string) (int, error) {
+	var agg struct {
+		Value int `json:"value"`
+	}
+
+	err := r.aggregation(name, &agg)
+	return agg.Value, err
+}
+
+// Decode named aggregation
+func (r *SearchResult) aggregation(name string, data interface{}) error {
+	raw, ok := r.Aggregations[name]
+	if !ok {
+		return fmt.Errorf("aggregation %s not found", name)
+	}
+
+	if err := json.Unmarshal(raw, data); err != nil {
+		return fmt.Errorf("error parsing aggregation %s: %s", name, err)
+	}
+
+	return nil
+}
+
+// totalHits : total hits format is {"value": 1, "relation": "eq"} since elasticsearch 7, number before that
+type totalHits int
+
+func (t *totalHits) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Value int `json:"value"`
+	}
+	if err := json.Unmarshal(data, &v); err == nil {
+		*t = totalHits(v.Value)
+		return nil
+	}
+
+	var n int
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*t = totalHits(n)
+
+	return nil
+}
+
+// Search : search documents from specified index, returns hits, total hits, aggregations and suggestions
+func Search(es *elasticsearch.Client, index string, q *Query) (r SearchResult, err error) {
+	if len(index) == 0 {
+		return r, ErrInvalidIndex
+	}
+
+	s, err := q.GetJSON()
+	if err != nil {
+		return r, err
+	}
+
+	res, err := es.Search(
+		es.Search.WithContext(context.Background()),
+		es.Search.WithIndex(index),
+		es.Search.WithBody(strings.NewReader(s)),
+		es.Search.WithTrackTotalHits(true),
+	)
+	if err != nil {
+		return r, transportError(err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return r, responseError(res)
+	}
+
+	var body struct {
+		TimedOut bool `json:"timed_out"`
+		Hits     struct {
+			Total    totalHits `json:"total"`
+			MaxScore *float64  `json:"max_score"`
+			Hits     []Hit     `json:"hits"`
+		} `json:"hits"`
+		Aggregations map[string]json.RawMessage `json:"aggregations"`
+		Suggest      map[string][]SuggestEntry  `json:"suggest"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return r, fmt.Errorf("[%s] error parsing the response body: %s", res.Status(), err)
+	}
+
+	// Partial result is not used
+	if body.TimedOut {
+		return r, &Error{Status: res.StatusCode, Type: ErrTimeout.Type, Reason: "search timed out"}
+	}
+
+	r.Total = int(body.Hits.Total)
+	if body.Hits.MaxScore != nil {
+		r.MaxScore = *body.Hits.MaxScore
+	}
+	r.Hits = body.Hits.Hits
+	r.Aggregations = body.Aggregations
+	r.Suggest = body.Suggest
+
+	return r, nil
+}
@@ -0,0 +1,65 @@
+// Package proxy contains helper functions for requests passing through api gateway and load balancers
+package proxy
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Trusted is list of api gateway and load balancer networks,
+// only requests coming from them can set identity and forwarding headers
+type Trusted []*net.IPNet
+
+// ParseTrusted : parse addresses or CIDRs of trusted proxies, invalid entries are skipped
+func ParseTrusted(proxies []string) Trusted {
+	var t Trusted
+	for _, p := range proxies {
+		if !strings.Contains(p, "/") {
+			if strings.Contains(p, ":") {
+				p += "/128"
+			} else {
+				p += "/32"
+			}
+		}
+
+		_, n, err := net.ParseCIDR(p)
+		if err != nil {
+			log.Printf("invalid trusted proxy %s: %v", p, err)
+			continue
+		}
+		t = append(t, n)
+	}
+
+	return t
+}
+
+// Contains : check if address is a trusted proxy
+func (t Trusted) Contains(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+
+	for _, n := range t {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// FromTrusted : check if the request connection comes from a trusted proxy
+func (t Trusted) FromTrusted(r *http.Request) bool {
+	return t.Contains(RemoteHost(r))
+}
+
+// RemoteHost : host of the request connection address
+func RemoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
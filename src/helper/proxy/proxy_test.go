@@ -0,0 +1,47 @@
+package proxy
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestTrustedContains(t *testing.T) {
+	trusted := ParseTrusted([]string{"127.0.0.1", "::1", "10.0.0.0/8", "invalid"})
+	if len(trusted) != 3 {
+		t.Fatalf("expected 3 trusted networks, got %d", len(trusted))
+	}
+
+	cases := map[string]bool{
+		"127.0.0.1":   true,
+		"::1":         true,
+		"10.1.2.3":    true,
+		"127.0.0.2":   false,
+		"192.168.0.1": false,
+		"":            false,
+		"not-an-ip":   false,
+	}
+	for address, want := range cases {
+		if got := trusted.Contains(address); got != want {
+			t.Errorf("Contains(%q) = %v, want %v", address, got, want)
+		}
+	}
+}
+
+func TestFromTrusted(t *testing.T) {
+	trusted := ParseTrusted([]string{"10.0.0.0/8"})
+
+	r := &http.Request{RemoteAddr: "10.0.0.5:51234"}
+	if !trusted.FromTrusted(r) {
+		t.Error("expected request from 10.0.0.5 to be trusted")
+	}
+
+	r = &http.Request{RemoteAddr: "203.0.113.7:51234"}
+	if trusted.FromTrusted(r) {
+		t.Error("expected request from 203.0.113.7 not to be trusted")
+	}
+
+	r = &http.Request{RemoteAddr: "[::1]:51234"}
+	if got := RemoteHost(r); got != "::1" {
+		t.Errorf("RemoteHost = %q, want ::1", got)
+	}
+}
@@ -0,0 +1,293 @@
+// Package redis contains helper functions for operating with redis
+package redis
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// ErrLockNotHeld is returned when releasing or extending lock which is not held (expired or acquired by other)
+var ErrLockNotHeld = errors.New("lock is not held")
+
+// Clock drift between redis servers, added to lock validity time (redlock)
+const (
+	lockDriftFactor = 0.01
+	lockDriftMin    = 2 * time.Millisecond
+)
+
+// lockReleaseScript : delete the lock only if it is held by the owner (token)
+var lockReleaseScript = redis.NewScript(1, `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// lockExtendScript : reset lock ttl (milliseconds) only if it is held by the owner (token)
+var lockExtendScript = redis.NewScript(1, `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// Lock is distributed lock (redlock). The lock is held if it is acquired in majority of
+// independent redis servers (pools) before it expires. Single pool is a simple redis lock.
+// Lock value is random token, so only the owner can release or extend it
+type Lock struct {
+	key   string
+	ttl   time.Duration
+	pools []*redis.Pool
+
+	mu      sync.Mutex
+	token   string
+	until   time.Time     // lock validity, lock may be held by other after this
+	stop    chan struct{} // stops auto extension
+	stopped sync.WaitGroup
+}
+
+// NewLock : create lock of the key in redis servers, the lock expires after ttl if it is not extended
+func NewLock(key string, ttl time.Duration, pools ...*redis.Pool) *Lock {
+	return &Lock{
+		key:   key,
+		ttl:   ttl,
+		pools: pools,
+	}
+}
+
+// TryLock : acquire the lock once, returns false if it is held by other
+func (l *Lock) TryLock() (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.pools) == 0 {
+		return false, fmt.Errorf("no redis pool to lock key %s", l.key)
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return false, err
+	}
+	token := hex.EncodeToString(b)
+
+	start := time.Now()
+	ok, err := l.quorum(func(conn redis.Conn) (bool, error) {
+		_, err := redis.String(conn.Do("SET", l.key, token, "NX", "PX", milliseconds(l.ttl)))
+		if err == redis.ErrNil {
+			return false, nil
+		}
+		return err == nil, err
+	})
+
+	until := l.validUntil(start)
+	if !ok || !time.Now().Before(until) {
+		// Release partially acquired lock
+		l.release(token)
+
+		if err != nil {
+			return false, fmt.Errorf("error locking key %s: %v", l.key, err)
+		}
+		return false, nil
+	}
+
+	l.token = token
+	l.until = until
+
+	return true, nil
+}
+
+// Lock : acquire the lock, retry until timeout. Returns false if the lock is still held by other
+func (l *Lock) Lock(timeout, retryDelay time.Duration) (bool, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		ok, err := l.TryLock()
+		if ok || err != nil {
+			return ok, err
+		}
+
+		if !time.Now().Add(retryDelay).Before(deadline) {
+			return false, nil
+		}
+		time.Sleep(retryDelay)
+	}
+}
+
+// Unlock : release the lock and stop auto extension, returns ErrLockNotHeld if the lock is expired
+// or held by other (the lock of other is not released)
+func (l *Lock) Unlock() error {
+	l.stopExtend()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.token) == 0 {
+		return ErrLockNotHeld
+	}
+
+	token, until := l.token, l.until
+	l.token = ""
+
+	held, err := l.release(token)
+	if err != nil {
+		return err
+	}
+	if !held || time.Now().After(until) {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
+
+// Extend : reset lock ttl, returns ErrLockNotHeld if the lock is expired or held by other
+func (l *Lock) Extend() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.token) == 0 {
+		return ErrLockNotHeld
+	}
+
+	start := time.Now()
+	ok, err := l.quorum(func(conn redis.Conn) (bool, error) {
+		n, err := redis.Int(lockExtendScript.Do(conn, l.key, l.token, milliseconds(l.ttl)))
+		return n == 1, err
+	})
+	if err != nil && !ok {
+		return fmt.Errorf("error extending lock %s: %v", l.key, err)
+	}
+
+	until := l.validUntil(start)
+	if !ok || !time.Now().Before(until) {
+		return ErrLockNotHeld
+	}
+
+	l.until = until
+
+	return nil
+}
+
+// AutoExtend : extend the lock every third of ttl until Unlock.
+// Returned channel is closed if the lock can not be extended (lock is lost)
+func (l *Lock) AutoExtend() <-chan struct{} {
+	l.stopExtend()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stop := make(chan struct{})
+	lost := make(chan struct{})
+	l.stop = stop
+	interval := l.ttl / 3
+
+	l.stopped.Add(1)
+	go func() {
+		defer l.stopped.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				// Extension may fail temporarily, the lock is lost only if it is expired
+				if err := l.Extend(); err == ErrLockNotHeld || (err != nil && !l.Valid()) {
+					close(lost)
+					return
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return lost
+}
+
+// Valid : check if the lock is held and not expired
+func (l *Lock) Valid() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.token) > 0 && time.Now().Before(l.until)
+}
+
+func (l *Lock) stopExtend() {
+	l.mu.Lock()
+	if l.stop != nil {
+		close(l.stop)
+		l.stop = nil
+	}
+	l.mu.Unlock()
+
+	l.stopped.Wait()
+}
+
+// Run command in every redis server, returns true if it succeeds in majority of servers.
+// Error of the last failed server is returned
+func (l *Lock) quorum(do func(conn redis.Conn) (bool, error)) (bool, error) {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		n       int
+		lastErr error
+	)
+
+	for _, pool := range l.pools {
+		wg.Add(1)
+		go func(pool *redis.Pool) {
+			defer wg.Done()
+
+			conn := pool.Get()
+			defer conn.Close()
+
+			ok, err := do(conn)
+
+			mu.Lock()
+			if ok {
+				n++
+			}
+			if err != nil {
+				lastErr = err
+			}
+			mu.Unlock()
+		}(pool)
+	}
+	wg.Wait()
+
+	return n >= len(l.pools)/2+1, lastErr
+}
+
+// Lock validity after acquired or extended at start, minus clock drift
+func (l *Lock) validUntil(start time.Time) time.Time {
+	drift := time.Duration(float64(l.ttl)*lockDriftFactor) + lockDriftMin
+	return start.Add(l.ttl - drift)
+}
+
+// Release the lock in every redis server, lock in unavailable server is released by ttl.
+// Returns true if the lock was held in majority of servers
+func (l *Lock) release(token string) (bool, error) {
+	var released int32
+	ok, err := l.quorum(func(conn redis.Conn) (bool, error) {
+		n, err := redis.Int(lockReleaseScript.Do(conn, l.key, token))
+		if n == 1 {
+			atomic.AddInt32(&released, 1)
+		}
+		return err == nil, err
+	})
+	if !ok && err != nil {
+		return false, fmt.Errorf("error unlocking key %s: %v", l.key, err)
+	}
+
+	return int(released) >= len(l.pools)/2+1, nil
+}
+
+func milliseconds(d time.Duration) int64 {
+	return int64(d / time.Millisecond)
+}
@@ -0,0 +1,153 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gomodule/redigo/redis"
+)
+
+func newTestPool(t *testing.T) (*miniredis.Miniredis, *redis.Pool) {
+	t.Helper()
+
+	m := miniredis.RunT(t)
+	addr := m.Addr()
+	pool := &redis.Pool{
+		MaxIdle: 2,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", addr)
+		},
+	}
+	t.Cleanup(func() { pool.Close() })
+
+	return m, pool
+}
+
+func TestLockTryLock(t *testing.T) {
+	_, pool := newTestPool(t)
+
+	l1 := NewLock("lock:test", time.Second, pool)
+	l2 := NewLock("lock:test", time.Second, pool)
+
+	if ok, err := l1.TryLock(); !ok || err != nil {
+		t.Fatalf("first TryLock = %v, %v; want true, nil", ok, err)
+	}
+	if ok, err := l2.TryLock(); ok || err != nil {
+		t.Fatalf("second TryLock = %v, %v; want false, nil", ok, err)
+	}
+
+	if err := l1.Unlock(); err != nil {
+		t.Fatalf("Unlock = %v; want nil", err)
+	}
+	if ok, err := l2.TryLock(); !ok || err != nil {
+		t.Fatalf("TryLock after Unlock = %v, %v; want true, nil", ok, err)
+	}
+}
+
+func TestLockUnlockByOtherToken(t *testing.T) {
+	m, pool := newTestPool(t)
+
+	l1 := NewLock("lock:test", time.Second, pool)
+	l2 := NewLock("lock:test", time.Second, pool)
+
+	if ok, _ := l1.TryLock(); !ok {
+		t.Fatal("l1 TryLock failed")
+	}
+
+	// The lock of l1 expires in redis and is acquired by l2
+	m.FastForward(2 * time.Second)
+	if ok, _ := l2.TryLock(); !ok {
+		t.Fatal("l2 TryLock after expiry failed")
+	}
+	token, _ := m.Get("lock:test")
+
+	if err := l1.Unlock(); err != ErrLockNotHeld {
+		t.Errorf("l1 Unlock = %v; want ErrLockNotHeld", err)
+	}
+	if got, _ := m.Get("lock:test"); got != token {
+		t.Errorf("lock value = %q after other owner unlocked; want %q", got, token)
+	}
+
+	if err := l2.Unlock(); err != nil {
+		t.Errorf("l2 Unlock = %v; want nil", err)
+	}
+	if m.Exists("lock:test") {
+		t.Error("lock exists after owner unlocked")
+	}
+}
+
+func TestLockExtend(t *testing.T) {
+	m, pool := newTestPool(t)
+
+	l := NewLock("lock:test", time.Second, pool)
+	if ok, _ := l.TryLock(); !ok {
+		t.Fatal("TryLock failed")
+	}
+
+	m.FastForward(500 * time.Millisecond)
+	if err := l.Extend(); err != nil {
+		t.Fatalf("Extend = %v; want nil", err)
+	}
+	if ttl := m.TTL("lock:test"); ttl != time.Second {
+		t.Errorf("ttl after Extend = %s; want 1s", ttl)
+	}
+}
+
+func TestLockExtendAfterExpiry(t *testing.T) {
+	m, pool := newTestPool(t)
+
+	l := NewLock("lock:test", time.Second, pool)
+	if ok, _ := l.TryLock(); !ok {
+		t.Fatal("TryLock failed")
+	}
+
+	m.FastForward(2 * time.Second)
+	if err := l.Extend(); err != ErrLockNotHeld {
+		t.Errorf("Extend after expiry = %v; want ErrLockNotHeld", err)
+	}
+	if m.Exists("lock:test") {
+		t.Error("expired lock is created again by Extend")
+	}
+}
+
+func TestLockAutoExtendLost(t *testing.T) {
+	m, pool := newTestPool(t)
+
+	l := NewLock("lock:test", 300*time.Millisecond, pool)
+	if ok, _ := l.TryLock(); !ok {
+		t.Fatal("TryLock failed")
+	}
+	lost := l.AutoExtend()
+	defer l.Unlock()
+
+	// Other owner takes the lock
+	m.Set("lock:test", "other")
+
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		t.Fatal("lost channel is not closed after the lock is taken by other")
+	}
+	if got, _ := m.Get("lock:test"); got != "other" {
+		t.Errorf("lock value = %q; want lock of other owner", got)
+	}
+}
+
+func TestLockQuorum(t *testing.T) {
+	_, pool1 := newTestPool(t)
+	_, pool2 := newTestPool(t)
+	m3, pool3 := newTestPool(t)
+
+	// Lock is acquired in majority of servers
+	m3.Close()
+	l := NewLock("lock:test", time.Second, pool1, pool2, pool3)
+	if ok, _ := l.TryLock(); !ok {
+		t.Fatal("TryLock with 2 of 3 servers failed")
+	}
+
+	other := NewLock("lock:test", time.Second, pool1, pool2, pool3)
+	if ok, _ := other.TryLock(); ok {
+		t.Fatal("lock held in majority of servers is acquired by other")
+	}
+}
@@ -0,0 +1,219 @@
+// Package redis contains helper functions for operating with redis
+package redis
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// Default pool settings
+const (
+	defaultPort        = 6379
+	defaultMaxIdle     = 80
+	defaultIdleTimeout = 240 * time.Second
+	defaultTimeout     = time.Second
+)
+
+// PoolBuilder is redis connection pool builder, stores redis connect information
+type PoolBuilder struct {
+	hostname string
+	port     int
+	password string
+	database int
+
+	useTLS        bool
+	tlsSkipVerify bool
+
+	connectTimeout time.Duration
+	readTimeout    time.Duration
+	writeTimeout   time.Duration
+
+	maxIdle     int
+	maxActive   int
+	idleTimeout time.Duration
+	wait        bool
+
+	sentinel *sentinel
+}
+
+// InitPool : Initialize redis connection pool builder
+func InitPool(hostname string, port int) *PoolBuilder {
+	var p PoolBuilder
+	p.hostname = hostname
+	p.port = port
+	return &p
+}
+
+// Credential : Add password, empty password means no authentication
+func (p *PoolBuilder) Credential(password string) *PoolBuilder {
+	p.password = password
+
+	return p
+}
+
+// Database : Select database index
+func (p *PoolBuilder) Database(db int) *PoolBuilder {
+	p.database = db
+
+	return p
+}
+
+// TLS : Connect using TLS
+func (p *PoolBuilder) TLS(skipVerify bool) *PoolBuilder {
+	p.useTLS = true
+	p.tlsSkipVerify = skipVerify
+
+	return p
+}
+
+// Timeout : Set connect, read and write timeout, zero uses default timeout (1 second)
+func (p *PoolBuilder) Timeout(connect, read, write time.Duration) *PoolBuilder {
+	p.connectTimeout = connect
+	p.readTimeout = read
+	p.writeTimeout = write
+
+	return p
+}
+
+// Size : Set maximum idle and active (zero means unlimited) connections.
+// Idle connections are closed after idle timeout.
+// If wait is true, Get waits for a connection when the pool is full instead of returning error
+func (p *PoolBuilder) Size(maxIdle, maxActive int, idleTimeout time.Duration, wait bool) *PoolBuilder {
+	p.maxIdle = maxIdle
+	p.maxActive = maxActive
+	p.idleTimeout = idleTimeout
+	p.wait = wait
+
+	return p
+}
+
+// Sentinel : Get master address from redis sentinels instead of hostname and port,
+// so the pool connects to the new master after failover
+func (p *PoolBuilder) Sentinel(masterName string, addresses []string, password string) *PoolBuilder {
+	if len(masterName) == 0 || len(addresses) == 0 {
+		p.sentinel = nil
+		return p
+	}
+
+	p.sentinel = &sentinel{
+		masterName: masterName,
+		addresses:  append([]string(nil), addresses...),
+		password:   password,
+	}
+
+	return p
+}
+
+// Connect using pool builder information. Connection is not tested, connections are dialed when used,
+// so the pool can be created while redis is not available (use Ping to test it).
+// Usage : InitPool( ... , ...)
+//
+//	.Credential( ... )
+//	.Sentinel( ... , ... , ... )
+//	.Connect()
+func (p *PoolBuilder) Connect() (*redis.Pool, error) {
+	if len(p.hostname) == 0 && p.sentinel == nil {
+		return nil, fmt.Errorf("invalid hostname")
+	}
+
+	// Default values
+	if p.port <= 0 {
+		p.port = defaultPort
+	}
+	if p.connectTimeout <= 0 {
+		p.connectTimeout = defaultTimeout
+	}
+	if p.readTimeout <= 0 {
+		p.readTimeout = defaultTimeout
+	}
+	if p.writeTimeout <= 0 {
+		p.writeTimeout = defaultTimeout
+	}
+	if p.maxIdle <= 0 {
+		p.maxIdle = defaultMaxIdle
+	}
+	if p.idleTimeout <= 0 {
+		p.idleTimeout = defaultIdleTimeout
+	}
+
+	if p.sentinel != nil {
+		p.sentinel.options = []redis.DialOption{
+			redis.DialConnectTimeout(p.connectTimeout),
+			redis.DialReadTimeout(p.readTimeout),
+			redis.DialWriteTimeout(p.writeTimeout),
+			redis.DialPassword(p.sentinel.password),
+		}
+	}
+
+	return &redis.Pool{
+		MaxIdle:      p.maxIdle,
+		MaxActive:    p.maxActive,
+		IdleTimeout:  p.idleTimeout,
+		Wait:         p.wait,
+		Dial:         p.dial,
+		TestOnBorrow: p.testOnBorrow,
+	}, nil
+}
+
+// Ping : test connection of the pool
+func Ping(pool *redis.Pool) error {
+	conn := pool.Get()
+	defer conn.Close()
+
+	if _, err := conn.Do("PING"); err != nil {
+		return fmt.Errorf("error connecting to redis: %v", err)
+	}
+	return nil
+}
+
+// Dial a new connection, returns error if redis is not available
+func (p *PoolBuilder) dial() (redis.Conn, error) {
+	address := fmt.Sprintf("%s:%d", p.hostname, p.port)
+	if p.sentinel != nil {
+		var err error
+		if address, err = p.sentinel.masterAddress(); err != nil {
+			return nil, err
+		}
+	}
+
+	options := []redis.DialOption{
+		redis.DialConnectTimeout(p.connectTimeout),
+		redis.DialReadTimeout(p.readTimeout),
+		redis.DialWriteTimeout(p.writeTimeout),
+		redis.DialPassword(p.password),
+		redis.DialDatabase(p.database),
+	}
+	if p.useTLS {
+		options = append(options, redis.DialUseTLS(true), redis.DialTLSSkipVerify(p.tlsSkipVerify))
+	}
+
+	c, err := redis.Dial("tcp", address, options...)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to redis %s: %v", address, err)
+	}
+
+	// Sentinel may return old master during failover
+	if p.sentinel != nil {
+		if err := testRole(c, "master"); err != nil {
+			c.Close()
+			return nil, err
+		}
+
+		// Connection to old master is closed when it rejects writes
+		return &masterConn{Conn: c}, nil
+	}
+
+	return c, nil
+}
+
+// Check connection which is idle longer than a minute before it is used,
+// closed connection is not returned to the caller
+func (p *PoolBuilder) testOnBorrow(c redis.Conn, t time.Time) error {
+	if time.Since(t) < time.Minute {
+		return nil
+	}
+	_, err := c.Do("PING")
+	return err
+}
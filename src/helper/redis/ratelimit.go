@@ -0,0 +1,135 @@
+// Package redis contains helper functions for operating with redis
+package redis
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// RateLimit is result of rate limiter, RetryAfter is set if the request is not allowed
+type RateLimit struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// slidingWindowScript : count requests in the last window using sorted set of request timestamps.
+// Redis server time is used, so every instance uses the same clock.
+// KEYS[1] is the request log, ARGV[1] is limit, ARGV[2] is window in microseconds, ARGV[3] is request id.
+// Returns allowed (0 or 1), remaining and retry after in microseconds
+var slidingWindowScript = redis.NewScript(1, `
+redis.replicate_commands()
+
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+
+local count = redis.call('ZCARD', KEYS[1])
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, t[1] .. '.' .. t[2] .. ':' .. ARGV[3])
+	redis.call('PEXPIRE', KEYS[1], math.ceil(window / 1000))
+	return {1, limit - count - 1, 0}
+end
+
+-- Allowed again when the oldest request leaves the window
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+return {0, 0, tonumber(oldest[2]) + window - now}
+`)
+
+// tokenBucketScript : take tokens from bucket which is refilled by rate tokens per second up to burst.
+// Bucket is stored as hash of tokens and last refill time (redis server time in microseconds).
+// KEYS[1] is the bucket, ARGV[1] is rate, ARGV[2] is burst, ARGV[3] is cost.
+// Returns allowed (0 or 1), remaining and retry after in microseconds
+var tokenBucketScript = redis.NewScript(1, `
+redis.replicate_commands()
+
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1]) or burst
+local ts = tonumber(bucket[2]) or now
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000000)
+
+local allowed = 0
+local retry = 0
+if tokens >= cost then
+	allowed = 1
+	tokens = tokens - cost
+else
+	retry = math.ceil((cost - tokens) * 1000000 / rate)
+end
+
+-- Bucket is full again after burst / rate seconds, it does not need to be stored longer
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+
+return {allowed, math.floor(tokens), retry}
+`)
+
+// SlidingWindow : allow at most limit requests of the key in the last window (sliding window log).
+// Rejected requests are not counted
+func SlidingWindow(pool *redis.Pool, key string, limit int, window time.Duration) (r RateLimit, err error) {
+	if limit <= 0 || window <= 0 {
+		return r, fmt.Errorf("invalid rate limit %d per %s", limit, window)
+	}
+
+	// Requests in the same microsecond must be different members
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return r, err
+	}
+
+	conn := pool.Get()
+	defer conn.Close()
+
+	values, err := redis.Int64s(slidingWindowScript.Do(conn, key, limit, int64(window/time.Microsecond), hex.EncodeToString(b)))
+	if err != nil {
+		return r, fmt.Errorf("error checking rate limit %s: %v", key, err)
+	}
+
+	return rateLimitOf(values), nil
+}
+
+// TokenBucket : take cost tokens from the bucket of the key, the bucket is refilled by rate tokens
+// per second and holds at most burst tokens. Rejected requests do not take tokens
+func TokenBucket(pool *redis.Pool, key string, rate float64, burst, cost int) (r RateLimit, err error) {
+	if rate <= 0 || burst <= 0 || cost <= 0 || cost > burst {
+		return r, fmt.Errorf("invalid token bucket rate %g burst %d cost %d", rate, burst, cost)
+	}
+
+	conn := pool.Get()
+	defer conn.Close()
+
+	values, err := redis.Int64s(tokenBucketScript.Do(conn, key, rate, burst, cost))
+	if err != nil {
+		return r, fmt.Errorf("error checking rate limit %s: %v", key, err)
+	}
+
+	return rateLimitOf(values), nil
+}
+
+func rateLimitOf(values []int64) (r RateLimit) {
+	if len(values) < 3 {
+		return r
+	}
+
+	r.Allowed = values[0] == 1
+	r.Remaining = int(values[1])
+	r.RetryAfter = time.Duration(values[2]) * time.Microsecond
+
+	return r
+}
@@ -0,0 +1,85 @@
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSlidingWindow(t *testing.T) {
+	m, pool := newTestPool(t)
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.SetTime(start)
+
+	for i := 0; i < 3; i++ {
+		r, err := SlidingWindow(pool, "rate:test", 3, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !r.Allowed || r.Remaining != 2-i {
+			t.Fatalf("request %d = %+v; want allowed with %d remaining", i, r, 2-i)
+		}
+		m.SetTime(start.Add(time.Duration(i+1) * 10 * time.Second))
+	}
+
+	// The oldest request leaves the window 30 seconds later
+	r, err := SlidingWindow(pool, "rate:test", 3, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Allowed || r.Remaining != 0 || r.RetryAfter != 30*time.Second {
+		t.Fatalf("request over limit = %+v; want rejected, retry after 30s", r)
+	}
+
+	// Rejected request is not counted
+	m.SetTime(start.Add(time.Minute))
+	if r, _ := SlidingWindow(pool, "rate:test", 3, time.Minute); !r.Allowed || r.Remaining != 0 {
+		t.Fatalf("request after the oldest left the window = %+v; want allowed with 0 remaining", r)
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	m, pool := newTestPool(t)
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.SetTime(start)
+
+	// Full bucket holds burst tokens
+	r, err := TokenBucket(pool, "rate:test", 2, 4, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Allowed || r.Remaining != 1 {
+		t.Fatalf("first request = %+v; want allowed with 1 remaining", r)
+	}
+
+	// 2 tokens are missing, refilled in 1 second
+	r, _ = TokenBucket(pool, "rate:test", 2, 4, 3)
+	if r.Allowed || r.RetryAfter != time.Second {
+		t.Fatalf("request without tokens = %+v; want rejected, retry after 1s", r)
+	}
+
+	m.SetTime(start.Add(time.Second))
+	r, _ = TokenBucket(pool, "rate:test", 2, 4, 3)
+	if !r.Allowed || r.Remaining != 0 {
+		t.Fatalf("request after refill = %+v; want allowed with 0 remaining", r)
+	}
+
+	// Bucket is not refilled over burst
+	m.SetTime(start.Add(time.Hour))
+	r, _ = TokenBucket(pool, "rate:test", 2, 4, 1)
+	if !r.Allowed || r.Remaining != 3 {
+		t.Fatalf("request after long idle = %+v; want allowed with 3 remaining", r)
+	}
+}
+
+func TestRateLimitInvalid(t *testing.T) {
+	_, pool := newTestPool(t)
+
+	if _, err := SlidingWindow(pool, "rate:test", 0, time.Minute); err == nil {
+		t.Error("SlidingWindow with zero limit returns no error")
+	}
+	if _, err := TokenBucket(pool, "rate:test", 1, 2, 3); err == nil {
+		t.Error("TokenBucket with cost over burst returns no error")
+	}
+}
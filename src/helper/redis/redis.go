@@ -8,29 +8,6 @@ import (
 	"github.com/gomodule/redigo/redis"
 )
 
-// Connect to redis server
-func Connect(host string, port int) *redis.Pool {
-	return newPool(host, port)
-}
-
-func newPool(host string, port int) *redis.Pool {
-	return &redis.Pool{
-		// Maximum number of idle connections in the pool.
-		MaxIdle: 80,
-		// max number of connections
-		MaxActive: 12000,
-		// Dial is an application supplied function for creating and
-		// configuring a connection.
-		Dial: func() (redis.Conn, error) {
-			c, err := redis.Dial("tcp", fmt.Sprintf("%s:%d", host, port))
-			if err != nil {
-				panic(err.Error())
-			}
-			return c, nil
-		},
-	}
-}
-
 // Get redis string value
 func Get(pool *redis.Pool, key string) (string, error) {
 
@@ -0,0 +1,133 @@
+// Package redis contains helper functions for operating with redis
+package redis
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// sentinel gets redis master address from redis sentinels
+type sentinel struct {
+	mu         sync.Mutex
+	masterName string
+	addresses  []string
+	password   string
+	options    []redis.DialOption
+}
+
+// Ask sentinels for master address in order, the responding sentinel is asked first next time
+func (s *sentinel) masterAddress() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var lastErr error
+	for i, address := range s.addresses {
+		master, err := s.askMaster(address)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		s.addresses[0], s.addresses[i] = s.addresses[i], s.addresses[0]
+		return master, nil
+	}
+
+	return "", fmt.Errorf("error getting redis master %s from sentinels: %v", s.masterName, lastErr)
+}
+
+func (s *sentinel) askMaster(address string) (string, error) {
+	c, err := redis.Dial("tcp", address, s.options...)
+	if err != nil {
+		return "", err
+	}
+	defer c.Close()
+
+	res, err := redis.Strings(c.Do("SENTINEL", "get-master-addr-by-name", s.masterName))
+	if err == redis.ErrNil {
+		return "", fmt.Errorf("sentinel %s does not know master %s", address, s.masterName)
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(res) != 2 {
+		return "", fmt.Errorf("invalid sentinel %s response: %v", address, res)
+	}
+
+	return net.JoinHostPort(res[0], res[1]), nil
+}
+
+// Check redis role (master or slave) of the connection
+func testRole(c redis.Conn, expected string) error {
+	res, err := redis.Values(c.Do("ROLE"))
+	if err != nil {
+		return err
+	}
+	if len(res) == 0 {
+		return fmt.Errorf("invalid role response")
+	}
+
+	role, err := redis.String(res[0], nil)
+	if err != nil {
+		return err
+	}
+	if role != expected {
+		return fmt.Errorf("redis role is %s, expected %s", role, expected)
+	}
+
+	return nil
+}
+
+// masterConn is connection to redis master. After failover the old master becomes replica
+// and rejects writes (READONLY error), then the connection is closed by the pool instead of reused
+type masterConn struct {
+	redis.Conn
+	err error
+}
+
+// Do : send command and check if the server is still master
+func (c *masterConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	reply, err := c.Conn.Do(cmd, args...)
+	c.check(err)
+	return reply, err
+}
+
+// Receive : receive reply and check if the server is still master
+func (c *masterConn) Receive() (interface{}, error) {
+	reply, err := c.Conn.Receive()
+	c.check(err)
+	return reply, err
+}
+
+// DoWithTimeout : send command with read timeout and check if the server is still master,
+// the timeout is used by blocking commands and pub/sub (redis.ConnWithTimeout)
+func (c *masterConn) DoWithTimeout(timeout time.Duration, cmd string, args ...interface{}) (interface{}, error) {
+	reply, err := redis.DoWithTimeout(c.Conn, timeout, cmd, args...)
+	c.check(err)
+	return reply, err
+}
+
+// ReceiveWithTimeout : receive reply with read timeout and check if the server is still master
+func (c *masterConn) ReceiveWithTimeout(timeout time.Duration) (interface{}, error) {
+	reply, err := redis.ReceiveWithTimeout(c.Conn, timeout)
+	c.check(err)
+	return reply, err
+}
+
+// Err : error which makes the connection unusable
+func (c *masterConn) Err() error {
+	if c.err != nil {
+		return c.err
+	}
+	return c.Conn.Err()
+}
+
+func (c *masterConn) check(err error) {
+	if e, ok := err.(redis.Error); ok && strings.HasPrefix(string(e), "READONLY") {
+		c.err = e
+	}
+}
@@ -0,0 +1,119 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/alicebob/miniredis/v2/server"
+	"github.com/gomodule/redigo/redis"
+)
+
+// newTestSentinel starts redis master and a sentinel which returns the master address,
+// returns the master and pool connected through the sentinel
+func newTestSentinel(t *testing.T) (*miniredis.Miniredis, *redis.Pool) {
+	t.Helper()
+
+	master := miniredis.RunT(t)
+	host, port := master.Host(), master.Port()
+	register(t, master, "ROLE", func(c *server.Peer, cmd string, args []string) {
+		c.WriteLen(3)
+		c.WriteBulk("master")
+		c.WriteInt(0)
+		c.WriteLen(0)
+	})
+
+	s := miniredis.RunT(t)
+	register(t, s, "SENTINEL", func(c *server.Peer, cmd string, args []string) {
+		if len(args) != 2 || args[0] != "get-master-addr-by-name" || args[1] != "mymaster" {
+			c.WriteNull()
+			return
+		}
+		c.WriteStrings([]string{host, port})
+	})
+
+	pool, err := InitPool("", 0).
+		Sentinel("mymaster", []string{"127.0.0.1:1", s.Addr()}, "").
+		Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pool.Close() })
+
+	return master, pool
+}
+
+func register(t *testing.T, m *miniredis.Miniredis, cmd string, f server.Cmd) {
+	t.Helper()
+
+	if err := m.Server().Register(cmd, f); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSentinelDial(t *testing.T) {
+	master, pool := newTestSentinel(t)
+
+	conn := pool.Get()
+	defer conn.Close()
+
+	if _, err := conn.Do("SET", "key", "value"); err != nil {
+		t.Fatalf("SET through sentinel: %v", err)
+	}
+	if v, _ := master.Get("key"); v != "value" {
+		t.Fatalf("master value = %q, want value", v)
+	}
+
+	v, err := redis.String(redis.DoWithTimeout(conn, time.Second, "GET", "key"))
+	if err != nil || v != "value" {
+		t.Fatalf("DoWithTimeout GET = %q, %v; want value, nil", v, err)
+	}
+}
+
+func TestSentinelReceiveWithTimeout(t *testing.T) {
+	master, pool := newTestSentinel(t)
+
+	psc := redis.PubSubConn{Conn: pool.Get()}
+	defer psc.Close()
+
+	if err := psc.Subscribe("channel"); err != nil {
+		t.Fatal(err)
+	}
+	if v := psc.ReceiveWithTimeout(time.Second); v != (redis.Subscription{Kind: "subscribe", Channel: "channel", Count: 1}) {
+		t.Fatalf("expected subscription to channel, got %v", v)
+	}
+
+	master.Publish("channel", "message")
+	switch m := psc.ReceiveWithTimeout(time.Second).(type) {
+	case redis.Message:
+		if string(m.Data) != "message" {
+			t.Fatalf("message = %q, want message", m.Data)
+		}
+	default:
+		t.Fatalf("expected message, got %v", m)
+	}
+
+	// No message, the receive times out
+	if v := psc.ReceiveWithTimeout(50 * time.Millisecond); v == nil {
+		t.Fatal("expected timeout error")
+	} else if _, ok := v.(error); !ok {
+		t.Fatalf("expected timeout error, got %v", v)
+	}
+}
+
+func TestSentinelReadOnly(t *testing.T) {
+	master, pool := newTestSentinel(t)
+	register(t, master, "READONLYSET", func(c *server.Peer, cmd string, args []string) {
+		c.WriteError("READONLY You can't write against a read only replica.")
+	})
+
+	conn := pool.Get()
+	defer conn.Close()
+
+	if _, err := redis.DoWithTimeout(conn, time.Second, "READONLYSET", "key", "value"); err == nil {
+		t.Fatal("expected READONLY error")
+	}
+	if conn.Err() == nil {
+		t.Fatal("expected connection to old master to be unusable")
+	}
+}
@@ -49,6 +49,20 @@ func (w *Writer) Error(err error) {
 	w.writeJSON(data, http.StatusInternalServerError)
 }
 
+// BadRequest response, used for invalid request parameters
+func (w *Writer) BadRequest(err error) {
+	// Error response format
+	data := struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}{
+		"002",
+		err.Error(),
+	}
+
+	w.writeJSON(data, http.StatusBadRequest)
+}
+
 // Write JSON response
 func (w *Writer) writeJSON(data interface{}, status int) {
 	b, err := json.Marshal(data)
@@ -60,3 +74,31 @@ func (w *Writer) writeJSON(data interface{}, status int) {
 	w.Writer.WriteHeader(status)
 	w.Writer.Write(b)
 }
+
+// NotFound response, used when requested data does not exist
+func (w *Writer) NotFound(err error) {
+	// Error response format
+	data := struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}{
+		"003",
+		err.Error(),
+	}
+
+	w.writeJSON(data, http.StatusNotFound)
+}
+
+// Forbidden response, used when user is not allowed to do the action
+func (w *Writer) Forbidden(err error) {
+	// Error response format
+	data := struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}{
+		"004",
+		err.Error(),
+	}
+
+	w.writeJSON(data, http.StatusForbidden)
+}
@@ -4,8 +4,13 @@ package news
 import (
 	"errors"
 	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
 	//"sync"
 
+	"github.com/filiadielias/kmpr-test/src/author"
 	"github.com/filiadielias/kmpr-test/src/general"
 	"github.com/filiadielias/kmpr-test/src/helper/db"
 	"github.com/filiadielias/kmpr-test/src/helper/elastic"
@@ -43,22 +48,28 @@ import (
 func InsertNews(n *News) error {
 	//n := News{Author: "", Body: ""}
 
+	// Link news to author, author name is taken from author data if author id is set
+	if err := n.setAuthor(); err != nil {
+		return err
+	}
+
+	n.setSlug()
+
+	if len(n.Status) == 0 {
+		n.Status = StatusDraft
+	}
+
 	// Insert to database
 	if err := n.insert(); err != nil {
 		return err
 	}
 
-	data := struct {
-		ID      int    `json:"id"`
-		Created string `json:"created"`
-	}{
-		n.ID,
-		// Format timestamp for compatibility with elasticsearch Date format
-		// make sure the time has 6-digit fractional second
-		n.Created.Format("2006-01-02 15:04:05.000000"),
+	// Only published news are searchable
+	if n.Status != StatusPublished {
+		return nil
 	}
 
-	err := elastic.AddDocument(kmpr.ES, "news", fmt.Sprintf("%d", n.ID), data)
+	err := elastic.AddDocument(kmpr.ES, "news", fmt.Sprintf("%d", n.ID), n.document())
 	if err != nil {
 		return err
 	}
@@ -66,36 +77,400 @@ func InsertNews(n *News) error {
 	return nil
 }
 
-// AddNews to publish news to consumers
-func AddNews(author, body string) error {
-	n := News{Author: author, Body: body}
+// UpdateNews to update news content in database, reindex if the news is published
+func UpdateNews(n *News) error {
+	if err := n.setAuthor(); err != nil {
+		return err
+	}
+
+	if err := n.update(); err != nil {
+		return err
+	}
+
+	// Only published news are searchable
+	if n.Status != StatusPublished {
+		return nil
+	}
+
+	return elastic.AddDocument(kmpr.ES, "news", fmt.Sprintf("%d", n.ID), n.document())
+}
+
+// ChangeStatus to move news through editorial workflow,
+// news is added to elasticsearch when published and removed when unpublished.
+// publishAt is required when status is scheduled
+func ChangeStatus(id int, status, role string, publishAt time.Time) (n News, err error) {
+	if !IsValidRole(role) {
+		return n, ErrForbidden
+	}
+
+	qb := db.QueryBuilder{}
+	qb.AddFilter("id", id, "=")
+	if err := n.getFromDB(&qb); err != nil {
+		return n, err
+	}
+
+	if err := checkTransition(n.Status, status, role); err != nil {
+		return n, err
+	}
+
+	var at *time.Time
+	switch status {
+	case StatusScheduled:
+		if !publishAt.After(time.Now()) {
+			return n, ErrInvalidPublishAt
+		}
+
+		// Database precision is microsecond
+		t := publishAt.UTC().Truncate(time.Microsecond)
+		at = &t
+	case StatusPublished:
+		t := time.Now().UTC().Truncate(time.Microsecond)
+		at = &t
+	}
+
+	// Elasticsearch is changed first, so the status is not changed if indexing failed
+	// and the request can be retried. Index change is rolled back if status update failed
+	docID := fmt.Sprintf("%d", n.ID)
+	switch {
+	case status == StatusPublished:
+		if err := elastic.AddDocument(kmpr.ES, "news", docID, n.document()); err != nil {
+			return n, err
+		}
+	case n.Status == StatusPublished:
+		if err := elastic.DeleteDocument(kmpr.ES, "news", docID); err != nil {
+			return n, err
+		}
+	}
+
+	from := n.Status
+	if err := n.updateStatus(status, at); err != nil {
+		var rerr error
+		switch {
+		case status == StatusPublished:
+			rerr = elastic.DeleteDocument(kmpr.ES, "news", docID)
+		case from == StatusPublished:
+			rerr = elastic.AddDocument(kmpr.ES, "news", docID, n.document())
+		}
+		if rerr != nil {
+			log.Printf("fail to roll back index of news %d: %v", id, rerr)
+		}
+
+		return n, err
+	}
+
+	// News is published by the publish job if the message is not sent
+	if status == StatusScheduled {
+		if err := schedulePublish(n); err != nil {
+			log.Printf("fail to schedule news %d, it is published by job: %v", n.ID, err)
+		}
+	}
+
+	return n, nil
+}
+
+// DeleteNews to move news to trash, news is removed from elasticsearch
+func DeleteNews(id int) (n News, err error) {
+	n.ID = id
+	if err := n.delete(); err != nil {
+		return n, err
+	}
+
+	if n.Status != StatusPublished {
+		return n, nil
+	}
+
+	err = elastic.DeleteDocument(kmpr.ES, "news", fmt.Sprintf("%d", n.ID))
+	return n, err
+}
+
+// RestoreNews to restore news from trash, news is added to elasticsearch if it is published
+func RestoreNews(id int) (n News, err error) {
+	n.ID = id
+	if err := n.restore(); err != nil {
+		return n, err
+	}
+
+	qb := db.QueryBuilder{}
+	qb.AddFilter("id", id, "=")
+	if err := n.getFromDB(&qb); err != nil {
+		return n, err
+	}
+
+	if n.Status != StatusPublished {
+		return n, nil
+	}
+
+	err = elastic.AddDocument(kmpr.ES, "news", fmt.Sprintf("%d", n.ID), n.document())
+	return n, err
+}
+
+// GetTrash getting list of deleted news, last deleted first
+func GetTrash(page, size int) (ns Newses, err error) {
+	if size <= 0 {
+		return ns, errors.New("Invalid size number")
+	}
+
+	if page <= 0 {
+		page = 1
+	}
+
+	qb := db.QueryBuilder{}
+	qb.Page = page
+	qb.Limit = size
+	qb.AddSort("deleted_at", "desc")
+	qb.AddSort("id", "desc")
+
+	// Empty page is not an error
+	if err := ns.getDeletedFromDB(&qb); err != nil && err != ErrNotFound {
+		return ns, err
+	}
+
+	return ns, nil
+}
+
+// PurgeTrash to permanently delete news which are in trash longer than retention.
+// Nothing is deleted (ErrStopped) if stop is closed before the deletion is committed
+func PurgeTrash(retention time.Duration, stop <-chan struct{}) (int64, error) {
+	if retention <= 0 {
+		return 0, errors.New("Invalid retention")
+	}
+
+	return purge(retention, stop)
+}
+
+// Check if job must be stopped, nil channel is never closed
+func stopped(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// PublishMessage is NSQ message to publish scheduled news
+type PublishMessage struct {
+	ID        int
+	PublishAt time.Time
+}
+
+// Send deferred message to publish the news at the scheduled time
+func schedulePublish(n News) error {
+	m := PublishMessage{ID: n.ID, PublishAt: *n.PublishAt}
+
+	return publish("NEWS_PUBLISH", m, time.Until(m.PublishAt))
+}
+
+// PublishScheduled to publish scheduled news, returns remaining time if it is not the time yet.
+// ErrNotScheduled is returned if news is unscheduled, published, or rescheduled to another time
+func PublishScheduled(m PublishMessage) (n News, wait time.Duration, err error) {
+	qb := db.QueryBuilder{}
+	qb.AddFilter("id", m.ID, "=")
+	if err := n.getFromDB(&qb); err != nil {
+		return n, wait, err
+	}
+
+	if n.Status != StatusScheduled || n.PublishAt == nil || !n.PublishAt.Equal(m.PublishAt) {
+		return n, wait, ErrNotScheduled
+	}
+
+	if wait = time.Until(m.PublishAt); wait > 0 {
+		return n, wait, nil
+	}
+
+	// Index first, if indexing failed the news stays scheduled and the message is retried.
+	// Index is rolled back if status update failed, scheduled news must not be searchable
+	docID := fmt.Sprintf("%d", n.ID)
+	if err := elastic.AddDocument(kmpr.ES, "news", docID, n.document()); err != nil {
+		return n, wait, err
+	}
+
+	if err := n.updateStatus(StatusPublished, nil); err != nil {
+		if rerr := elastic.DeleteDocument(kmpr.ES, "news", docID); rerr != nil {
+			log.Printf("fail to roll back index of news %d: %v", n.ID, rerr)
+		}
+
+		return n, wait, err
+	}
+
+	return n, wait, nil
+}
+
+// PublishDue to publish scheduled news which publish time has passed, at most limit news.
+// Used when the NSQ message is lost (failed to send or dropped), returns published news
+func PublishDue(limit int, stop <-chan struct{}) (published Newses, err error) {
+	qb := db.QueryBuilder{}
+	qb.Limit = limit
+	qb.AddFilter("status", StatusScheduled, "=")
+	qb.AddFilter("publish_at", time.Now(), "<=")
+	qb.AddSort("publish_at", "asc")
+
+	var ns Newses
+	if err := ns.getFromDB(&qb); err == ErrNotFound {
+		return published, nil
+	} else if err != nil {
+		return published, err
+	}
+
+	for _, n := range ns {
+		if stopped(stop) {
+			return published, ErrStopped
+		}
+
+		p, _, err := PublishScheduled(PublishMessage{ID: n.ID, PublishAt: *n.PublishAt})
+		if err == ErrNotScheduled || err == ErrNotFound {
+			// Changed by other (e.g. published by the NSQ consumer)
+			continue
+		}
+		if err != nil {
+			return published, err
+		}
+
+		published = append(published, p)
+	}
+
+	return published, nil
+}
+
+// GetNewsByStatus getting list of news with specified status from database, newest first
+func GetNewsByStatus(status string, page, size int) (ns Newses, err error) {
+	if !IsValidStatus(status) {
+		return ns, ErrInvalidStatus
+	}
+
+	if size <= 0 {
+		return ns, errors.New("Invalid size number")
+	}
+
+	if page <= 0 {
+		page = 1
+	}
+
+	qb := db.QueryBuilder{}
+	qb.Page = page
+	qb.Limit = size
+	qb.AddFilter("status", status, "=")
+	qb.AddSort("created", "desc")
+	qb.AddSort("id", "desc")
+
+	// Empty page is not an error
+	if err := ns.getFromDB(&qb); err != nil && err != ErrNotFound {
+		return ns, err
+	}
+
+	return ns, nil
+}
+
+// ReindexNews to add all published news to elasticsearch in batches ordered by id,
+// used after fields are added to the index mapping. Returns number of indexed news
+func ReindexNews(batchSize int) (count int, err error) {
+	if batchSize <= 0 {
+		return count, errors.New("Invalid batch size")
+	}
+
+	lastID := 0
+	for {
+		qb := db.QueryBuilder{}
+		qb.Limit = batchSize
+		qb.AddFilter("status", StatusPublished, "=")
+		qb.AddFilter("id", lastID, ">")
+		qb.AddSort("id", "asc")
+
+		var ns Newses
+		if err := ns.getFromDB(&qb); err == ErrNotFound {
+			return count, nil
+		} else if err != nil {
+			return count, err
+		}
+
+		for _, n := range ns {
+			if err := elastic.AddDocument(kmpr.ES, "news", fmt.Sprintf("%d", n.ID), n.document()); err != nil {
+				return count, fmt.Errorf("error indexing news %d: %v", n.ID, err)
+			}
+			count++
+		}
+
+		if len(ns) < batchSize {
+			return count, nil
+		}
+		lastID = ns[len(ns)-1].ID
+	}
+}
+
+// AddNews to publish news to consumers, news is added as draft
+func AddNews(n News) error {
+	if utf8.RuneCountInString(n.Title) > 255 {
+		return ErrTitleTooLong
+	}
+
+	if err := n.validateAuthor(); err != nil {
+		return err
+	}
+
+	if err := n.validateTaxonomy(); err != nil {
+		return err
+	}
+
+	n.Status = StatusDraft
 
-	// Publish to NSQ
+	return publish("NEWS_ADD", n, 0)
+}
+
+// EditNews to publish news update to consumers, every update is saved as new revision
+func EditNews(n News) error {
+	if n.ID <= 0 {
+		return ErrNotFound
+	}
+
+	if utf8.RuneCountInString(n.Title) > 255 {
+		return ErrTitleTooLong
+	}
+
+	if err := n.validateAuthor(); err != nil {
+		return err
+	}
+
+	if err := n.validateTaxonomy(); err != nil {
+		return err
+	}
+
+	return publish("NEWS_UPDATE", n, 0)
+}
+
+// Publish message to NSQ topic, message is delivered after delay if delay is set.
+// NSQ limits the delay (max-req-timeout, 1 hour by default), consumer must requeue
+// the message if it is not the time yet
+func publish(topic string, data interface{}, delay time.Duration) error {
 	config := nsq.NewConfig()
 	w, err := nsq.NewProducer(fmt.Sprintf("%s:%d", kmpr.Config.NSQ.Producer.Host, kmpr.Config.NSQ.Producer.Port), config)
 	if err != nil {
 		return err
 	}
+	defer w.Stop()
 
 	// Encode data to bytes
-	b, err := general.GobEncode(n)
+	b, err := general.GobEncode(data)
 	if err != nil {
 		return err
 	}
 
-	// Publish to nsq
-	if err := w.Publish("NEWS_ADD", b); err != nil {
-		return err
+	if delay > MaxPublishDelay {
+		delay = MaxPublishDelay
 	}
-	w.Stop()
 
-	return nil
+	// Publish to nsq
+	if delay > 0 {
+		return w.DeferredPublish(topic, delay, b)
+	}
+	return w.Publish(topic, b)
 }
 
-// GetNews getting list of news by page and speficy size per page
-func GetNews(page, size int) (ns Newses, err error) {
+// GetNews getting list of news by page and speficy size per page,
+// returns the news and total number of news.
+// If elasticsearch is not available, news are taken from database (degraded)
+func GetNews(page, size int, f Filter) (ns Newses, total int, degraded bool, err error) {
 	if size <= 0 {
-		return ns, errors.New("Invalid size number")
+		return ns, total, degraded, errors.New("Invalid size number")
 	}
 
 	if page <= 0 {
@@ -103,37 +478,354 @@ func GetNews(page, size int) (ns Newses, err error) {
 	}
 
 	// Set filter, sort information
-	eq := elastic.Query{}
+	eq := newsQuery(size, f)
 	eq.Page = page
-	eq.Limit = size
-	eq.Sort = map[string]string{
-		"created": "desc",
+
+	// Get from elastic
+	ids, total, err := searchIDs(&eq)
+	if err != nil {
+		// Elasticsearch failed, get directly from database
+		if err != ErrSearchUnavailable {
+			log.Println(err)
+		}
+
+		ns, total, err = getByPageFromDB(page, size, f)
+		return ns, total, true, err
+	}
+
+	ns, err = getByIDs(ids)
+	return ns, total, false, err
+}
+
+// GetNewsByCursor getting list of news after the cursor (keyset pagination),
+// returns the news and cursor for the next page (empty if there is no next page).
+// If elasticsearch is not available, news are taken from database (degraded)
+func GetNewsByCursor(cursor string, size int, f Filter) (ns Newses, next string, degraded bool, err error) {
+	if size <= 0 {
+		return ns, next, degraded, errors.New("Invalid size number")
+	}
+
+	c, err := DecodeCursor(cursor)
+	if err != nil {
+		return ns, next, degraded, err
+	}
+
+	// Set filter, sort information
+	eq := newsQuery(size, f)
+	if !c.IsZero() {
+		// Elasticsearch date sort value is in epoch milliseconds
+		eq.SearchAfter = []interface{}{c.Created.UnixNano() / int64(time.Millisecond), c.ID}
 	}
 
 	// Get from elastic
-	ids, err := elastic.GetDocuments(kmpr.ES, "news", &eq)
+	ids, _, err := searchIDs(&eq)
+	if err == nil {
+		ns, err = getByIDs(ids)
+	} else {
+		// Elasticsearch failed, get directly from database
+		if err != ErrSearchUnavailable {
+			log.Println(err)
+		}
+
+		degraded = true
+		ns, err = getByCursorFromDB(c, size, f)
+	}
 	if err != nil {
+		return ns, next, degraded, err
+	}
+
+	if len(ns) == size {
+		next = cursorOf(ns[len(ns)-1]).Encode()
+	}
+
+	return ns, next, degraded, nil
+}
+
+// Build elasticsearch query from filter, id is used as sort tie breaker
+func newsQuery(size int, f Filter) elastic.Query {
+	eq := elastic.Query{}
+	eq.Limit = size
+	eq.Sort = []map[string]string{
+		{"created": f.order()},
+		{"id": f.order()},
+	}
+
+	if len(f.Author) > 0 {
+		eq.AddFilter(map[string]interface{}{
+			"term": map[string]interface{}{"author.keyword": f.Author},
+		})
+	}
+
+	if f.AuthorID > 0 {
+		eq.AddFilter(map[string]interface{}{
+			"term": map[string]interface{}{"author_id": f.AuthorID},
+		})
+	}
+
+	if len(f.Tag) > 0 {
+		eq.AddFilter(map[string]interface{}{
+			"term": map[string]interface{}{"tags": f.Tag},
+		})
+	}
+
+	if len(f.Category) > 0 {
+		eq.AddFilter(map[string]interface{}{
+			"term": map[string]interface{}{"category": f.Category},
+		})
+	}
+
+	if !f.From.IsZero() || !f.To.IsZero() {
+		r := map[string]interface{}{}
+		if !f.From.IsZero() {
+			r["gte"] = f.From.UTC().Format(esDateFormat)
+		}
+		if !f.To.IsZero() {
+			r["lte"] = f.To.UTC().Format(esDateFormat)
+		}
+
+		eq.AddFilter(map[string]interface{}{
+			"range": map[string]interface{}{"created": r},
+		})
+	}
+
+	return eq
+}
+
+// Database query of published news matching the filter, same as newsQuery
+func filterQuery(f Filter) db.QueryBuilder {
+	qb := db.QueryBuilder{}
+	qb.AddFilter("status", StatusPublished, "=")
+
+	if len(f.Author) > 0 {
+		qb.AddFilter("author", f.Author, "=")
+	}
+	if f.AuthorID > 0 {
+		qb.AddFilter("author_id", f.AuthorID, "=")
+	}
+	if len(f.Tag) > 0 {
+		qb.AddSubqueryFilter("id", "in", "select nt.news_id from news_tags nt join tags t on t.id = nt.tag_id where t.slug = ?", f.Tag)
+	}
+	if len(f.Category) > 0 {
+		qb.AddSubqueryFilter("category_id", "=", "select id from categories where slug = ?", f.Category)
+	}
+	if !f.From.IsZero() {
+		qb.AddFilter("created", f.From.UTC(), ">=")
+	}
+	if !f.To.IsZero() {
+		qb.AddFilter("created", f.To.UTC(), "<=")
+	}
+
+	return qb
+}
+
+// Get news page from database ordered by (created, id), returns the news and total number of news
+func getByPageFromDB(page, size int, f Filter) (ns Newses, total int, err error) {
+	// Count with separate query builder, deleted filter is added by each query
+	cq := filterQuery(f)
+	if total, err = ns.countFromDB(&cq); err != nil {
+		return ns, total, err
+	}
+
+	qb := filterQuery(f)
+	qb.Page = page
+	qb.Limit = size
+	qb.AddSort("created", f.order())
+	qb.AddSort("id", f.order())
+
+	if err := ns.getFromDB(&qb); err != nil && err != ErrNotFound {
+		return ns, total, err
+	}
+
+	return ns, total, nil
+}
+
+// Get news from database ordered by (created, id), start after the cursor
+func getByCursorFromDB(c Cursor, size int, f Filter) (ns Newses, err error) {
+	qb := filterQuery(f)
+	qb.Limit = size
+	qb.AddSort("created", f.order())
+	qb.AddSort("id", f.order())
+
+	if !c.IsZero() {
+		operator := "<"
+		if f.order() == "asc" {
+			operator = ">"
+		}
+
+		err := qb.SetKeyset([]string{"created", "id"}, []interface{}{c.Created, c.ID}, operator)
+		if err != nil {
+			return ns, err
+		}
+	}
+
+	if err := ns.getFromDB(&qb); err != nil && err != ErrNotFound {
 		return ns, err
 	}
 
+	return ns, nil
+}
+
+// IsPublished : check if news is published, not deleted news
+func IsPublished(id int) (bool, error) {
+	_, err := getPublished(id)
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Get published news by id
+func getPublished(id int) (n News, err error) {
+	qb := db.QueryBuilder{}
+	qb.AddFilter("id", id, "=")
+	qb.AddFilter("status", StatusPublished, "=")
+
+	err = n.getFromDB(&qb)
+	return n, err
+}
+
+// Get news detail for each id, keep the order of ids.
+// News which are not found in database (e.g. deleted) are skipped
+func getByIDs(ids []int) (ns Newses, err error) {
 	// Append all news first
 	for i := 0; i < len(ids); i++ {
 		ns = append(ns, News{ID: ids[i]})
 	}
 
-	ch := make(chan error)
+	ch := make(chan error, len(ns))
 	for i := 0; i < len(ns); i++ {
 		go ns[i].get(ch)
 	}
 
+	// Wait for every goroutine, they are still writing into ns until they send the result
 	for i := 0; i < len(ns); i++ {
-		err := <-ch
+		if e := <-ch; e != nil && e != ErrNotFound && err == nil {
+			err = e
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Remove news which are not found
+	found := ns[:0]
+	for _, n := range ns {
+		if !n.Created.IsZero() {
+			found = append(found, n)
+		}
+	}
+
+	return found, nil
+}
+
+// GetNewsBySlug getting published news detail by slug
+func GetNewsBySlug(slug string) (n News, err error) {
+	if len(slug) == 0 {
+		return n, ErrNotFound
+	}
+
+	qb := db.QueryBuilder{}
+	qb.AddFilter("slug", slug, "=")
+	qb.AddFilter("status", StatusPublished, "=")
+
+	err = n.getFromDB(&qb)
+	return n, err
+}
+
+// Set news author id and name
+func (n *News) setAuthor() error {
+	if n.AuthorID > 0 {
+		a, err := author.GetAuthor(n.AuthorID)
 		if err != nil {
-			return ns, err
+			return err
 		}
+
+		n.Author = a.Name
+		return nil
 	}
 
-	return ns, nil
+	a, err := author.GetOrCreateAuthor(n.Author)
+	if err != nil {
+		return err
+	}
+
+	n.AuthorID = a.ID
+	return nil
+}
+
+// Check author before publishing to consumer, consumer can not save news without author
+func (n *News) validateAuthor() error {
+	if n.AuthorID > 0 {
+		_, err := author.GetAuthor(n.AuthorID)
+		if err == author.ErrNotFound {
+			return ErrInvalidAuthor
+		}
+		return err
+	}
+
+	if len(general.Slugify(n.Author)) == 0 {
+		return ErrInvalidAuthor
+	}
+
+	return nil
+}
+
+// Generate slug from title, or from the beginning of body if title is empty.
+// Slug uniqueness is handled on insert
+func (n *News) setSlug() {
+	s := n.Title
+	if len(strings.TrimSpace(s)) == 0 {
+		s = n.Body
+	}
+
+	slug := general.Slugify(s)
+	if len(slug) > maxSlugLength {
+		slug = slug[:maxSlugLength]
+
+		// Cut on word boundary
+		if i := strings.LastIndex(slug, "-"); i > 0 {
+			slug = slug[:i]
+		}
+	}
+
+	if len(slug) == 0 {
+		slug = "news"
+	}
+
+	n.Slug = slug
+}
+
+// Get elasticsearch document of the news
+func (n *News) document() interface{} {
+	category, tags := n.taxonomySlugs()
+
+	return struct {
+		ID       int      `json:"id"`
+		AuthorID int      `json:"author_id"`
+		Author   string   `json:"author"`
+		Title    string   `json:"title"`
+		Slug     string   `json:"slug"`
+		Summary  string   `json:"summary"`
+		Body     string   `json:"body"`
+		Category string   `json:"category"`
+		Tags     []string `json:"tags"`
+		Suggest  []string `json:"suggest"`
+		Created  string   `json:"created"`
+	}{
+		n.ID,
+		n.AuthorID,
+		n.Author,
+		n.Title,
+		n.Slug,
+		n.Summary,
+		n.Body,
+		category,
+		tags,
+		n.suggestInput(),
+		// Format timestamp for compatibility with elasticsearch Date format
+		// make sure the time has 6-digit fractional second
+		n.Created.UTC().Format(esDateFormat),
+	}
 }
 
 // Get news detail
@@ -0,0 +1,53 @@
+// Package news contains business logic from news, store to database, etc
+package news
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// Cursor is position of the last news in a page, used for keyset pagination.
+// News are ordered by (created, id), so the cursor stores both values.
+type Cursor struct {
+	Created time.Time `json:"c"`
+	ID      int       `json:"i"`
+}
+
+// IsZero : check if cursor is empty (first page)
+func (c Cursor) IsZero() bool {
+	return c.ID == 0 && c.Created.IsZero()
+}
+
+// Encode cursor into opaque string, safe to use in URL
+func (c Cursor) Encode() string {
+	if c.IsZero() {
+		return ""
+	}
+
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor : decode cursor string, empty string means first page
+func DecodeCursor(s string) (c Cursor, err error) {
+	if len(s) == 0 {
+		return c, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(b, &c); err != nil || c.ID <= 0 {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+// cursorOf : get cursor of the given news
+func cursorOf(n News) Cursor {
+	return Cursor{Created: n.Created, ID: n.ID}
+}
@@ -0,0 +1,140 @@
+// Package news contains business logic from news, store to database, etc
+package news
+
+import (
+	"strings"
+)
+
+// List of diff operations
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// DiffLine is a line of diff result
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// Maximum number of changed lines found by diffLines, memory grows with square of the changes.
+// Body with more changes is shown as whole body replaced
+const maxDiffEdits = 1000
+
+// diffLines : line based diff, lines are compared using Myers algorithm (shortest edit script)
+func diffLines(from, to string) []DiffLine {
+	a := strings.Split(from, "\n")
+	b := strings.Split(to, "\n")
+
+	// Common prefix and suffix are equal, only lines between them are compared
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var lines []DiffLine
+	for _, line := range a[:prefix] {
+		lines = append(lines, DiffLine{DiffEqual, line})
+	}
+	lines = append(lines, editScript(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		lines = append(lines, DiffLine{DiffEqual, line})
+	}
+
+	return lines
+}
+
+// Shortest edit script from a to b. Furthest reaching x of every diagonal k (x - y) is kept
+// for each number of edits d, then the path is traced back from the end.
+// If there are more than maxDiffEdits edits, a is deleted and b is inserted
+func editScript(a, b []string) []DiffLine {
+	limit := len(a) + len(b)
+	if limit > maxDiffEdits {
+		limit = maxDiffEdits
+	}
+
+	// v[off+k] is furthest x of diagonal k, trace[d][k+d] is v after d edits
+	off := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+
+	for d := 0; d <= limit; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1] // insert, move down from diagonal k+1
+			} else {
+				x = v[off+k-1] + 1 // delete, move right from diagonal k-1
+			}
+
+			y := x - k
+			for x < len(a) && y < len(b) && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+
+			if x >= len(a) && y >= len(b) {
+				trace = append(trace, append([]int(nil), v[off-d:off+d+1]...))
+				return traceBack(a, b, trace)
+			}
+		}
+
+		trace = append(trace, append([]int(nil), v[off-d:off+d+1]...))
+	}
+
+	var lines []DiffLine
+	for _, line := range a {
+		lines = append(lines, DiffLine{DiffDelete, line})
+	}
+	for _, line := range b {
+		lines = append(lines, DiffLine{DiffInsert, line})
+	}
+	return lines
+}
+
+// Build diff lines by following the edits of trace backward from the end of a and b
+func traceBack(a, b []string, trace [][]int) []DiffLine {
+	var lines []DiffLine
+	x, y := len(a), len(b)
+
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1] // prev[k+d-1] is furthest x of diagonal k after d-1 edits
+		k := x - y
+
+		prevK := k - 1
+		if k == -d || (k != d && prev[k-1+d-1] < prev[k+1+d-1]) {
+			prevK = k + 1
+		}
+		prevX := prev[prevK+d-1]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			lines = append(lines, DiffLine{DiffEqual, a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			lines = append(lines, DiffLine{DiffInsert, b[y-1]})
+		} else {
+			lines = append(lines, DiffLine{DiffDelete, a[x-1]})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		lines = append(lines, DiffLine{DiffEqual, a[x-1]})
+		x--
+		y--
+	}
+
+	// Lines are collected from the end
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return lines
+}
@@ -0,0 +1,84 @@
+package news
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Apply diff lines to rebuild both sides
+func applyDiff(lines []DiffLine) (from, to string) {
+	var a, b []string
+	for _, l := range lines {
+		switch l.Op {
+		case DiffEqual:
+			a = append(a, l.Text)
+			b = append(b, l.Text)
+		case DiffDelete:
+			a = append(a, l.Text)
+		case DiffInsert:
+			b = append(b, l.Text)
+		}
+	}
+	return strings.Join(a, "\n"), strings.Join(b, "\n")
+}
+
+func countChanges(lines []DiffLine) (changes int) {
+	for _, l := range lines {
+		if l.Op != DiffEqual {
+			changes++
+		}
+	}
+	return changes
+}
+
+func TestDiffLines(t *testing.T) {
+	cases := []struct {
+		from, to string
+		changes  int
+	}{
+		{"", "", 0},
+		{"a\nb\nc", "a\nb\nc", 0},
+		{"a\nb\nc", "a\nc", 1},
+		{"a\nc", "a\nb\nc", 1},
+		{"a\nb\nc", "x\ny\nz", 6},
+		{"a\nb\nc\na\nb\nb\na", "c\nb\na\nb\na\nc", 5},
+		{"first\nsecond", "", 3},
+		{"", "first\nsecond", 3},
+	}
+
+	for _, c := range cases {
+		lines := diffLines(c.from, c.to)
+
+		from, to := applyDiff(lines)
+		if from != c.from || to != c.to {
+			t.Errorf("diff of %q and %q rebuilds %q and %q", c.from, c.to, from, to)
+		}
+		if changes := countChanges(lines); changes != c.changes {
+			t.Errorf("diff of %q and %q has %d changes, want %d", c.from, c.to, changes, c.changes)
+		}
+	}
+}
+
+func TestDiffLinesTooManyChanges(t *testing.T) {
+	var a, b []string
+	for i := 0; i < maxDiffEdits; i++ {
+		a = append(a, fmt.Sprintf("old %d", i))
+		b = append(b, fmt.Sprintf("new %d", i))
+	}
+	from := "title\n" + strings.Join(a, "\n") + "\nend"
+	to := "title\n" + strings.Join(b, "\n") + "\nend"
+
+	lines := diffLines(from, to)
+
+	rebuiltFrom, rebuiltTo := applyDiff(lines)
+	if rebuiltFrom != from || rebuiltTo != to {
+		t.Fatal("diff does not rebuild both bodies")
+	}
+	if changes := countChanges(lines); changes != 2*maxDiffEdits {
+		t.Errorf("expected whole body replaced (%d changes), got %d", 2*maxDiffEdits, changes)
+	}
+	if lines[0].Op != DiffEqual || lines[len(lines)-1].Op != DiffEqual {
+		t.Error("expected common first and last lines to be equal")
+	}
+}
@@ -0,0 +1,87 @@
+// Package news contains business logic from news, store to database, etc
+package news
+
+import (
+	"github.com/filiadielias/kmpr-test/src/helper/elastic"
+)
+
+// Maximum number of values per facet
+const facetSize = 20
+
+// Facet is number of news for a category or tag
+type Facet struct {
+	Slug  string `json:"slug"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Facets is category and tag facets
+type Facets struct {
+	Categories []Facet `json:"categories"`
+	Tags       []Facet `json:"tags"`
+}
+
+// GetFacets getting number of news per category and tag, news are filtered using the filter
+func GetFacets(f Filter) (fs Facets, err error) {
+	eq := newsQuery(0, f)
+	eq.SkipHits = true
+	eq.AddTermsAggregation("categories", "category", facetSize)
+	eq.AddTermsAggregation("tags", "tags", facetSize)
+
+	r, err := search(&eq)
+	if err != nil {
+		return fs, err
+	}
+
+	for _, agg := range []struct {
+		table  string
+		facets *[]Facet
+	}{
+		{"categories", &fs.Categories},
+		{"tags", &fs.Tags},
+	} {
+		buckets, err := r.Terms(agg.table)
+		if err != nil {
+			return fs, err
+		}
+
+		if *agg.facets, err = toFacets(agg.table, buckets); err != nil {
+			return fs, err
+		}
+	}
+
+	return fs, nil
+}
+
+// Convert aggregation buckets to facets, names are taken from table (categories or tags)
+func toFacets(table string, buckets []elastic.Bucket) ([]Facet, error) {
+	fs := []Facet{}
+
+	var slugs []string
+	for _, b := range buckets {
+		// Empty category is indexed as empty string
+		if len(b.Key) > 0 {
+			slugs = append(slugs, b.Key)
+		}
+	}
+
+	names, err := getTaxonomyNames(table, slugs)
+	if err != nil {
+		return fs, err
+	}
+
+	for _, b := range buckets {
+		if len(b.Key) == 0 {
+			continue
+		}
+
+		name, ok := names[b.Key]
+		if !ok {
+			name = b.Key
+		}
+
+		fs = append(fs, Facet{Slug: b.Key, Name: name, Count: b.DocCount})
+	}
+
+	return fs, nil
+}
@@ -0,0 +1,124 @@
+// Package news contains business logic from news, store to database, etc
+package news
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/filiadielias/kmpr-test/src/general"
+)
+
+// List of sort options
+const (
+	SortCreatedDesc = "created_desc"
+	SortCreatedAsc  = "created_asc"
+)
+
+// Date format accepted by filter
+const filterDateFormat = "2006-01-02"
+
+// Filter is news list filter and sort option
+type Filter struct {
+	Author   string
+	AuthorID int
+	Tag      string // tag slug
+	Category string // category slug
+	From     time.Time
+	To       time.Time
+	Sort     string
+}
+
+// ParseFilter : parse and validate filter from request parameters (author, tag, category, from, to, sort),
+// from and to can be a date (2006-01-02) or RFC3339 timestamp
+func ParseFilter(q url.Values) (f Filter, err error) {
+	f.Author = strings.TrimSpace(q.Get("author"))
+	f.Tag = general.Slugify(q.Get("tag"))
+	f.Category = general.Slugify(q.Get("category"))
+
+	from, to, sort := q.Get("from"), q.Get("to"), q.Get("sort")
+
+	if len(from) > 0 {
+		if f.From, err = parseFilterDate(from, false); err != nil {
+			return f, ErrInvalidFromDate
+		}
+	}
+
+	if len(to) > 0 {
+		if f.To, err = parseFilterDate(to, true); err != nil {
+			return f, ErrInvalidToDate
+		}
+	}
+
+	if !f.From.IsZero() && !f.To.IsZero() && f.From.After(f.To) {
+		return f, ErrInvalidDateRange
+	}
+
+	switch sort {
+	case "":
+		f.Sort = SortCreatedDesc
+	case SortCreatedDesc, SortCreatedAsc:
+		f.Sort = sort
+	default:
+		return f, ErrInvalidSort
+	}
+
+	return f, nil
+}
+
+// Parse filter date, date without time on end of range means end of the day
+func parseFilterDate(s string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(filterDateFormat, s)
+	if err != nil {
+		return t, err
+	}
+
+	if end {
+		t = t.Add(24*time.Hour - time.Microsecond)
+	}
+
+	return t, nil
+}
+
+// Values : get filter as url values, empty values are omitted.
+// Used for cache key and page links
+func (f Filter) Values() url.Values {
+	v := url.Values{}
+
+	if len(f.Author) > 0 {
+		v.Set("author", f.Author)
+	}
+	if f.AuthorID > 0 {
+		v.Set("author_id", strconv.Itoa(f.AuthorID))
+	}
+	if len(f.Tag) > 0 {
+		v.Set("tag", f.Tag)
+	}
+	if len(f.Category) > 0 {
+		v.Set("category", f.Category)
+	}
+	if !f.From.IsZero() {
+		v.Set("from", f.From.Format(time.RFC3339Nano))
+	}
+	if !f.To.IsZero() {
+		v.Set("to", f.To.Format(time.RFC3339Nano))
+	}
+	if len(f.Sort) > 0 && f.Sort != SortCreatedDesc {
+		v.Set("sort", f.Sort)
+	}
+
+	return v
+}
+
+// order : get sort direction of created
+func (f Filter) order() string {
+	if f.Sort == SortCreatedAsc {
+		return "asc"
+	}
+	return "desc"
+}
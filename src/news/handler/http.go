@@ -6,9 +6,11 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/filiadielias/kmpr-test/src/author"
 	"github.com/filiadielias/kmpr-test/src/general"
-	"github.com/filiadielias/kmpr-test/src/helper/redis"
+	"github.com/filiadielias/kmpr-test/src/helper/cache"
 	writer_lib "github.com/filiadielias/kmpr-test/src/helper/writer"
 	"github.com/filiadielias/kmpr-test/src/news"
 
@@ -17,6 +19,30 @@ import (
 
 var kmpr *general.Module
 
+// Headers contain user information, set by api gateway after authentication
+const (
+	roleHeader = "X-User-Role"
+	userHeader = "X-User-ID"
+)
+
+// Header of news list response which is taken from database because elasticsearch is not available
+const degradedHeader = "X-Degraded-Mode"
+
+// News list cache is deleted when published news changed,
+// ttl makes sure it is refreshed even if deleting failed
+const newsCacheTTL = 10 * time.Minute
+
+// Cache tag of news list (page and cursor), used for deleting all news list cache
+const newsListTag = "news:list"
+
+// newsListFetcher prevents concurrent requests from loading the same news list,
+// previous list is served for another minute while it is refreshed
+var newsListFetcher = &cache.Fetcher{
+	TTL:      newsCacheTTL,
+	StaleTTL: time.Minute,
+	OnError:  func(err error) { log.Println(err) },
+}
+
 func init() {
 	kmpr = &general.KMPR
 }
@@ -34,85 +60,468 @@ func AddNewsHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
 		return
 	}
 
-	// Insert into database
-	if err := news.AddNews(n.Author, n.Body); err != nil {
+	// Inserted by consumer as draft, news list cache is cleared when the news is published
+	err := news.AddNews(news.News{
+		AuthorID: n.AuthorID,
+		Author:   n.Author,
+		Title:    n.Title,
+		Summary:  n.Summary,
+		Body:     n.Body,
+		Category: n.Category,
+		Tags:     n.Tags,
+		Editor:   r.Header.Get(userHeader),
+	})
+	if err == news.ErrTitleTooLong || err == news.ErrInvalidAuthor || err == news.ErrInvalidTaxonomy {
+		writer.BadRequest(err)
+		return
+	}
+	if err != nil {
 		log.Println(err)
 		writer.Error(err)
 		return
 	}
 	writer.Success(nil)
+}
+
+// UpdateNewsHandler : to handle update news endpoint, update is processed by consumer
+func UpdateNewsHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	writer := writer_lib.New(w)
 
-	// Delete stored cache (data is not up to date)
-	keys, err := redis.GetKeys(kmpr.Redis, "news:search:page:*")
+	if !news.IsValidRole(r.Header.Get(roleHeader)) {
+		writer.Forbidden(news.ErrInvalidRole)
+		return
+	}
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		writer.NotFound(news.ErrNotFound)
+		return
+	}
+
+	var n news.News
+	if err := general.JSONUnmarshal(r.Body, &n); err != nil {
+		writer.BadRequest(err)
+		return
+	}
+
+	err = news.EditNews(news.News{
+		ID:       id,
+		AuthorID: n.AuthorID,
+		Author:   n.Author,
+		Title:    n.Title,
+		Summary:  n.Summary,
+		Body:     n.Body,
+		Category: n.Category,
+		Tags:     n.Tags,
+		Editor:   r.Header.Get(userHeader),
+	})
+	if err == news.ErrTitleTooLong || err == news.ErrInvalidAuthor || err == news.ErrInvalidTaxonomy {
+		writer.BadRequest(err)
+		return
+	}
 	if err != nil {
 		log.Println(err)
 		writer.Error(err)
 		return
 	}
 
-	ch := make(chan error)
-	for i := 0; i < len(keys); i++ {
+	writer.Success(nil)
+}
 
-		go func(value string, ch chan<- error) {
+// ChangeNewsStatusHandler : move news through editorial workflow,
+// role is taken from X-User-Role header which is set by api gateway
+func ChangeNewsStatusHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	writer := writer_lib.New(w)
 
-			err := redis.Delete(kmpr.Redis, value)
-			ch <- err
-		}(keys[i], ch)
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		writer.NotFound(news.ErrNotFound)
+		return
 	}
 
-	for i := 0; i < len(keys); i++ {
-		err = <-ch
+	var req struct {
+		Status    string    `json:"status"`
+		PublishAt time.Time `json:"publish_at"` // required for scheduled status
+	}
+	if err := general.JSONUnmarshal(r.Body, &req); err != nil {
+		writer.BadRequest(err)
+		return
+	}
+
+	n, err := news.ChangeStatus(id, req.Status, r.Header.Get(roleHeader), req.PublishAt)
+	switch err {
+	case nil:
+	case news.ErrNotFound:
+		writer.NotFound(err)
+		return
+	case news.ErrForbidden:
+		writer.Forbidden(err)
+		return
+	case news.ErrInvalidStatus, news.ErrInvalidTransition, news.ErrInvalidPublishAt:
+		writer.BadRequest(err)
+		return
+	default:
 		log.Println(err)
+		writer.Error(err)
+		return
+	}
+
+	// Published news list is changed (archived news can only come from published)
+	if n.Status == news.StatusPublished || n.Status == news.StatusArchived {
+		clearNewsCache()
 	}
+	clearNewsItemCache(n.ID)
+
+	writer.Success(n)
 }
 
-// GetNewsHandler : get news by page number
-func GetNewsHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+// DeleteNewsHandler : move news to trash
+func DeleteNewsHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	writer := writer_lib.New(w)
+
+	if !news.CanManageTrash(r.Header.Get(roleHeader)) {
+		writer.Forbidden(news.ErrForbidden)
+		return
+	}
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		writer.NotFound(news.ErrNotFound)
+		return
+	}
+
+	n, err := news.DeleteNews(id)
+	if err == news.ErrNotFound {
+		writer.NotFound(err)
+		return
+	}
+
+	// Published news list is changed, even if removing from elasticsearch failed
+	if n.Status == news.StatusPublished {
+		clearNewsCache()
+	}
+	clearNewsItemCache(id)
+
+	if err != nil {
+		log.Println(err)
+		writer.Error(err)
+		return
+	}
+
+	writer.Success(nil)
+}
+
+// RestoreNewsHandler : restore news from trash
+func RestoreNewsHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	writer := writer_lib.New(w)
 
-	page := 0
+	if !news.CanManageTrash(r.Header.Get(roleHeader)) {
+		writer.Forbidden(news.ErrForbidden)
+		return
+	}
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		writer.NotFound(news.ErrNotFound)
+		return
+	}
+
+	n, err := news.RestoreNews(id)
+	if err == news.ErrNotFound {
+		writer.NotFound(err)
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		writer.Error(err)
+		return
+	}
+
+	if n.Status == news.StatusPublished {
+		clearNewsCache()
+	}
+	clearNewsItemCache(n.ID)
+
+	writer.Success(n)
+}
+
+// GetTrashHandler : get deleted news by page number
+func GetTrashHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	writer := writer_lib.New(w)
+
+	if !news.CanManageTrash(r.Header.Get(roleHeader)) {
+		writer.Forbidden(news.ErrForbidden)
+		return
+	}
+
 	page, err := strconv.Atoi(r.URL.Query().Get("page"))
 	if err != nil || page <= 0 {
 		page = 1
 	}
 
-	var ns news.Newses
+	size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+	size = kmpr.Config.PageSize(size)
 
-	// Check cache
-	key := fmt.Sprintf("news:search:page:%d", page)
-	exists, err := redis.Exists(kmpr.Redis, key)
+	ns, err := news.GetTrash(page, size)
 	if err != nil {
 		log.Println(err)
 		writer.Error(err)
 		return
 	}
-	// Get cache
-	if exists {
-		err = redis.GetStruct(kmpr.Redis, key, &ns)
 
-		// If not error, return the data
-		if err == nil {
-			writer.Success(ns)
-			return
-		}
+	writer.Success(ns)
+}
+
+// GetNewsByStatusHandler : get news by status (e.g. draft, in_review) for editorial team
+func GetNewsByStatusHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	writer := writer_lib.New(w)
+
+	if !news.IsValidRole(r.Header.Get(roleHeader)) {
+		writer.Forbidden(news.ErrInvalidRole)
+		return
+	}
 
-		// If error, display error then continue fetching from database
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+	size = kmpr.Config.PageSize(size)
+
+	ns, err := news.GetNewsByStatus(ps.ByName("status"), page, size)
+	if err == news.ErrInvalidStatus {
+		writer.BadRequest(err)
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		writer.Error(err)
+		return
+	}
+
+	writer.Success(ns)
+}
+
+// Delete stored news list cache (data is not up to date)
+func clearNewsCache() {
+	if err := kmpr.Cache.Invalidate(newsListTag); err != nil {
 		log.Println(err)
 	}
+}
 
-	// Fetching from database
-	ns, err = news.GetNews(page, 10) // Hardcoded temporarily
+// GetNewsBySlugHandler : get news detail by slug
+func GetNewsBySlugHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	writer := writer_lib.New(w)
+
+	n, err := news.GetNewsBySlug(ps.ByName("slug"))
+	if err == news.ErrNotFound {
+		writer.NotFound(err)
+		return
+	}
 	if err != nil {
 		log.Println(err)
 		writer.Error(err)
 		return
 	}
 
-	// Store to cache server
-	if err := redis.SetStruct(kmpr.Redis, key, ns); err != nil {
-		// Just display the error
+	writer.Success(n)
+}
+
+// newsPage is response of get news by page number
+type newsPage struct {
+	News       news.Newses  `json:"news"`
+	Page       int          `json:"page"`
+	Size       int          `json:"size"`
+	Total      int          `json:"total"`
+	TotalPages int          `json:"total_pages"`
+	Facets     *news.Facets `json:"facets,omitempty"`
+	Links      struct {
+		Next string `json:"next,omitempty"`
+		Prev string `json:"prev,omitempty"`
+	} `json:"links"`
+	Degraded bool `json:"degraded,omitempty"` // taken from database, elasticsearch is not available
+}
+
+// newsCursorPage is response of get news by cursor
+type newsCursorPage struct {
+	News       news.Newses `json:"news"`
+	Size       int         `json:"size"`
+	NextCursor string      `json:"next_cursor"`
+	Degraded   bool        `json:"degraded,omitempty"`
+}
+
+// GetNewsHandler : get news by page number, or by cursor if cursor parameter exists
+func GetNewsHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	writer := writer_lib.New(w)
+
+	filter, err := news.ParseFilter(r.URL.Query())
+	if err != nil {
+		writer.BadRequest(err)
+		return
+	}
+
+	getNewsList(writer, r, filter, "/news")
+}
+
+// GetAuthorNewsHandler : get news by author id, using the same parameters as GetNewsHandler
+func GetAuthorNewsHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	writer := writer_lib.New(w)
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		writer.NotFound(author.ErrNotFound)
+		return
+	}
+
+	// Make sure author exists
+	a, err := author.GetAuthor(id)
+	if err == author.ErrNotFound {
+		writer.NotFound(err)
+		return
+	}
+	if err != nil {
 		log.Println(err)
+		writer.Error(err)
+		return
 	}
 
-	writer.Success(ns)
+	// Author is taken from path
+	q := r.URL.Query()
+	q.Del("author")
+	filter, err := news.ParseFilter(q)
+	if err != nil {
+		writer.BadRequest(err)
+		return
+	}
+	filter.AuthorID = a.ID
+
+	getNewsList(writer, r, filter, fmt.Sprintf("/authors/%d/news", a.ID))
+}
+
+// Get news list by page number, or by cursor if cursor parameter exists.
+// path is used for next and previous page links
+func getNewsList(writer *writer_lib.Writer, r *http.Request, filter news.Filter, path string) {
+	size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+	size = kmpr.Config.PageSize(size)
+
+	if _, ok := r.URL.Query()["cursor"]; ok {
+		getNewsByCursor(writer, r, size, filter)
+		return
+	}
+
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	var resp newsPage
+
+	key := fmt.Sprintf("news:search:page:%d:size:%d:%s", page, size, filter.Values().Encode())
+	if news.SearchAvailable() {
+		err = newsListFetcher.Fetch(kmpr.Cache, key, &resp, func() (interface{}, error) {
+			resp, err := loadNewsPage(page, size, filter, path)
+			if resp.Degraded {
+				// Do not cache database result, elasticsearch result is cached when it is available
+				return cache.Uncacheable{Value: resp}, err
+			}
+			return resp, err
+		}, newsListTag)
+	} else {
+		// Database result is not cached, so requests do not wait for other instances (cache lock)
+		resp, err = loadNewsPage(page, size, filter, path)
+	}
+	if err != nil {
+		log.Println(err)
+		writer.Error(err)
+		return
+	}
+
+	setDegradedHeader(writer, resp.Degraded)
+	writer.Success(resp)
+}
+
+// Fetching news list page from elasticsearch and database
+func loadNewsPage(page, size int, filter news.Filter, path string) (resp newsPage, err error) {
+	resp.News, resp.Total, resp.Degraded, err = news.GetNews(page, size, filter)
+	if err != nil {
+		return resp, err
+	}
+
+	resp.Page = page
+	resp.Size = size
+	resp.TotalPages = (resp.Total + size - 1) / size
+
+	// Facets are optional (and need elasticsearch), display the error only
+	if !resp.Degraded {
+		if facets, err := news.GetFacets(filter); err == nil {
+			resp.Facets = &facets
+		} else {
+			log.Println(err)
+		}
+	}
+
+	// Set links to next and previous page, keep the filter
+	values := filter.Values()
+	values.Del("author_id") // already in path
+	values.Set("size", strconv.Itoa(size))
+	if page < resp.TotalPages {
+		values.Set("page", strconv.Itoa(page+1))
+		resp.Links.Next = fmt.Sprintf("%s%s?%s", kmpr.Config.App.Address, path, values.Encode())
+	}
+	if page > 1 {
+		values.Set("page", strconv.Itoa(page-1))
+		resp.Links.Prev = fmt.Sprintf("%s%s?%s", kmpr.Config.App.Address, path, values.Encode())
+	}
+
+	return resp, nil
+}
+
+// Get news using keyset pagination, empty cursor means first page
+func getNewsByCursor(writer *writer_lib.Writer, r *http.Request, size int, filter news.Filter) {
+	cursor := r.URL.Query().Get("cursor")
+
+	// Validate cursor before checking cache
+	if _, err := news.DecodeCursor(cursor); err != nil {
+		writer.BadRequest(err)
+		return
+	}
+
+	var resp newsCursorPage
+	var err error
+
+	key := fmt.Sprintf("news:search:page:cursor:%s:size:%d:%s", cursor, size, filter.Values().Encode())
+	if news.SearchAvailable() {
+		err = newsListFetcher.Fetch(kmpr.Cache, key, &resp, func() (interface{}, error) {
+			resp, err := loadNewsCursorPage(cursor, size, filter)
+			if resp.Degraded {
+				return cache.Uncacheable{Value: resp}, err
+			}
+			return resp, err
+		}, newsListTag)
+	} else {
+		resp, err = loadNewsCursorPage(cursor, size, filter)
+	}
+	if err != nil {
+		log.Println(err)
+		writer.Error(err)
+		return
+	}
+
+	setDegradedHeader(writer, resp.Degraded)
+	writer.Success(resp)
+}
+
+// Fetching news list by cursor from elasticsearch and database
+func loadNewsCursorPage(cursor string, size int, filter news.Filter) (resp newsCursorPage, err error) {
+	resp.News, resp.NextCursor, resp.Degraded, err = news.GetNewsByCursor(cursor, size, filter)
+	resp.Size = size
+	return resp, err
+}
+
+// Tell the client that news list is taken from database (no relevance, facets, etc)
+func setDegradedHeader(writer *writer_lib.Writer, degraded bool) {
+	if degraded {
+		writer.Writer.Header().Set(degradedHeader, "database")
+	}
 }
@@ -0,0 +1,114 @@
+// Package handler contains http handlers and NSQ consumers
+package handler
+
+import (
+	"log"
+	"time"
+
+	redis_lib "github.com/filiadielias/kmpr-test/src/helper/redis"
+	"github.com/filiadielias/kmpr-test/src/news"
+)
+
+// Jobs are run by one instance at a time, the lock is extended while the job is running
+const jobLockTTL = 30 * time.Second
+
+// Scheduled news which NSQ message is lost are published by the job
+const (
+	publishInterval  = time.Minute
+	publishBatchSize = 100
+)
+
+// InitJobs starts news background jobs, blocks forever
+func InitJobs() {
+	conf := kmpr.Config.Trash
+
+	interval := time.Duration(conf.PurgeIntervalMinutes) * time.Minute
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	retention := time.Duration(conf.RetentionDays) * 24 * time.Hour
+	if retention <= 0 {
+		retention = 30 * 24 * time.Hour
+	}
+
+	flushInterval := time.Duration(kmpr.Config.Views.FlushIntervalSeconds) * time.Second
+	if flushInterval <= 0 {
+		flushInterval = time.Minute
+	}
+
+	purgeTicker := time.NewTicker(interval)
+	flushTicker := time.NewTicker(flushInterval)
+	publishTicker := time.NewTicker(publishInterval)
+	for {
+		select {
+		case <-purgeTicker.C:
+			runExclusive("purge_trash", func(lost <-chan struct{}) { purgeTrash(retention, lost) })
+		case <-flushTicker.C:
+			runExclusive("flush_views", flushViews)
+		case <-publishTicker.C:
+			runExclusive("publish_due", publishDue)
+		}
+	}
+}
+
+// Run job if it is not running in other instance.
+// The job must stop when lost is closed, other instance may run it after the lock is lost
+func runExclusive(name string, job func(lost <-chan struct{})) {
+	l := redis_lib.NewLock("lock:job:"+name, jobLockTTL, kmpr.Redis)
+	ok, err := l.TryLock()
+	if err != nil {
+		log.Printf("fail to lock job %s: %v", name, err)
+		return
+	}
+	if !ok {
+		return
+	}
+	lost := l.AutoExtend()
+	defer l.Unlock()
+
+	job(lost)
+}
+
+// Publish scheduled news which publish time has passed
+func publishDue(lost <-chan struct{}) {
+	ns, err := news.PublishDue(publishBatchSize, lost)
+	if err != nil {
+		log.Println("fail to publish scheduled news:", err)
+	}
+
+	if len(ns) > 0 {
+		log.Printf("%d scheduled news published", len(ns))
+
+		// Published news list is changed
+		clearNewsCache()
+		for _, n := range ns {
+			clearNewsItemCache(n.ID)
+		}
+	}
+}
+
+// Permanently delete news in trash older than retention
+func purgeTrash(retention time.Duration, lost <-chan struct{}) {
+	count, err := news.PurgeTrash(retention, lost)
+	if err != nil {
+		log.Println("fail to purge trash:", err)
+		return
+	}
+
+	if count > 0 {
+		log.Printf("%d news purged from trash", count)
+	}
+}
+
+// Store view counters from redis to database
+func flushViews(lost <-chan struct{}) {
+	count, err := news.FlushViews(lost)
+	if err != nil {
+		log.Println("fail to flush views:", err)
+	}
+
+	if count > 0 {
+		log.Printf("views of %d news flushed", count)
+	}
+}
@@ -30,49 +30,144 @@ func (h *messageHandler) HandleMessage(m *nsq.Message) error {
 		return err
 	}
 
-	// Insert data
-	if err := news.InsertNews(&n); err != nil {
+	// Insert data. News is added as draft, news list cache is not changed
+	// (it is cleared when the news is published)
+	return news.InsertNews(&n)
+}
+
+type updateHandler struct{}
+
+// HandleMessage to handle update news nsq consumer
+func (h *updateHandler) HandleMessage(m *nsq.Message) error {
+
+	if len(m.Body) == 0 {
+		return fmt.Errorf("body is blank")
+	}
+
+	var n news.News
+
+	// Decode message
+	if err := general.GobDecode(m.Body, &n); err != nil {
+		return err
+	}
+
+	// Update data
+	err := news.UpdateNews(&n)
+	if err == news.ErrNotFound {
+		// News is deleted, drop the message
+		return nil
+	}
+	if err != nil {
 		return err
 	}
 
+	// Published news list contains the old content
+	if n.Status == news.StatusPublished {
+		clearNewsCache()
+	}
+	clearNewsItemCache(n.ID)
+
+	return nil
+}
+
+type publishHandler struct{}
+
+// HandleMessage to handle scheduled news publishing nsq consumer
+func (h *publishHandler) HandleMessage(m *nsq.Message) error {
+
+	if len(m.Body) == 0 {
+		return fmt.Errorf("body is blank")
+	}
+
+	var pm news.PublishMessage
+
+	// Decode message
+	if err := general.GobDecode(m.Body, &pm); err != nil {
+		return err
+	}
+
+	n, wait, err := news.PublishScheduled(pm)
+	switch {
+	case err == news.ErrNotScheduled || err == news.ErrNotFound:
+		// Unscheduled, rescheduled or deleted, drop the message
+		return nil
+	case err != nil:
+		return err
+	case wait > 0:
+		// Not the time yet (delay is limited by NSQ), requeue the message
+		if wait > news.MaxPublishDelay {
+			wait = news.MaxPublishDelay
+		}
+		m.RequeueWithoutBackoff(wait)
+		return nil
+	}
+
+	// Published news list is changed
+	clearNewsCache()
+	clearNewsItemCache(n.ID)
+
 	return nil
 }
 
 // InitNSQHandlers initialized nsq consumers then
 // make connection to NSQ server
 func InitNSQHandlers(host string, port int) error {
-	config := nsq.NewConfig()
-
-	consumer, err := nsq.NewConsumer("NEWS_ADD", "database", config)
-	if err != nil {
-		log.Panicf("fail to init NSQ consumer: %v", err)
-		return err
+	handlers := []struct {
+		topic       string
+		handler     nsq.Handler
+		concurrency int
+		configure   func(c *nsq.Config)
+	}{
+		{"NEWS_ADD", &messageHandler{}, 20, nil},
+		{"NEWS_UPDATE", &updateHandler{}, 20, nil},
+		{"NEWS_PUBLISH", &publishHandler{}, 5, func(c *nsq.Config) {
+			// Scheduled message is requeued until publish time, which may be days ahead.
+			// Default config finishes the message after 5 attempts of at most 15 minutes
+			c.MaxAttempts = 0
+			c.MaxRequeueDelay = news.MaxPublishDelay
+		}},
 	}
 
-	consumer.ChangeMaxInFlight(100)
+	var consumers []*nsq.Consumer
+	for _, h := range handlers {
+		config := nsq.NewConfig()
+		if h.configure != nil {
+			h.configure(config)
+		}
+
+		consumer, err := nsq.NewConsumer(h.topic, "database", config)
+		if err != nil {
+			log.Panicf("fail to init NSQ consumer: %v", err)
+			return err
+		}
 
-	consumer.AddConcurrentHandlers(
-		&messageHandler{},
-		20,
-	)
+		consumer.ChangeMaxInFlight(100)
 
-	if err := consumer.ConnectToNSQLookupds([]string{fmt.Sprintf("%s:%d", host, port)}); err != nil {
-		log.Panicf("fail to connect to nsqlookupd: %v", err)
-		return err
+		consumer.AddConcurrentHandlers(
+			h.handler,
+			h.concurrency,
+		)
+
+		if err := consumer.ConnectToNSQLookupds([]string{fmt.Sprintf("%s:%d", host, port)}); err != nil {
+			log.Panicf("fail to connect to nsqlookupd: %v", err)
+			return err
+		}
+
+		consumers = append(consumers, consumer)
 	}
 
 	// Listen to SIGINT (ctrl+c) to make sure the queues finish properly on shutdown
 	shutdown := make(chan os.Signal, 2)
 	signal.Notify(shutdown, syscall.SIGINT)
 
-	for {
-		select {
-		case <-consumer.StopChan:
-			return nil // Consumer disconnected
-		case <-shutdown:
-			// Synchronously drain the queue before falling out of main
-			consumer.Stop()
-		}
+	<-shutdown
+
+	// Synchronously drain the queues before falling out of main
+	for _, consumer := range consumers {
+		consumer.Stop()
+	}
+	for _, consumer := range consumers {
+		<-consumer.StopChan
 	}
 
 	return nil
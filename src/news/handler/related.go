@@ -0,0 +1,82 @@
+// Package handler contains http handlers and NSQ consumers
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/filiadielias/kmpr-test/src/helper/cache"
+	writer_lib "github.com/filiadielias/kmpr-test/src/helper/writer"
+	"github.com/filiadielias/kmpr-test/src/news"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// Related news cache is deleted when the news changed,
+// ttl is used for refreshing the related news content
+const relatedCacheTTL = time.Hour
+
+// GetRelatedNewsHandler : get published news which are similar to the news
+func GetRelatedNewsHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	writer := writer_lib.New(w)
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		writer.NotFound(news.ErrNotFound)
+		return
+	}
+
+	size, err := strconv.Atoi(r.URL.Query().Get("size"))
+	if err != nil || size <= 0 {
+		size = news.DefaultRelatedSize
+	}
+	if size > news.MaxRelatedSize {
+		size = news.MaxRelatedSize
+	}
+
+	// Maximum size is cached, so one key is used for every size
+	var ns news.Newses
+	key := relatedCacheKey(id)
+	if err := kmpr.Cache.Get(key, &ns); err != nil {
+		if err != cache.ErrMiss {
+			log.Println(err)
+		}
+
+		ns, err = news.GetRelatedNews(id, news.MaxRelatedSize)
+		if err == news.ErrNotFound {
+			writer.NotFound(err)
+			return
+		}
+		if err != nil {
+			log.Println(err)
+			writer.Error(err)
+			return
+		}
+
+		// Store to cache server
+		if err := kmpr.Cache.Set(key, ns, relatedCacheTTL); err != nil {
+			// Just display the error
+			log.Println(err)
+		}
+	}
+
+	if len(ns) > size {
+		ns = ns[:size]
+	}
+
+	writer.Success(ns)
+}
+
+func relatedCacheKey(id int) string {
+	return fmt.Sprintf("news:related:%d", id)
+}
+
+// Delete cache of the news: related news and published status (news content or status is changed)
+func clearNewsItemCache(id int) {
+	if err := kmpr.Cache.Delete(relatedCacheKey(id), publishedCacheKey(id)); err != nil {
+		log.Println(err)
+	}
+}
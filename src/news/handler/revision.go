@@ -0,0 +1,115 @@
+// Package handler contains http handlers and NSQ consumers
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	writer_lib "github.com/filiadielias/kmpr-test/src/helper/writer"
+	"github.com/filiadielias/kmpr-test/src/news"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// GetRevisionsHandler : get all revisions of the news
+func GetRevisionsHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	writer := writer_lib.New(w)
+
+	if !news.IsValidRole(r.Header.Get(roleHeader)) {
+		writer.Forbidden(news.ErrInvalidRole)
+		return
+	}
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		writer.NotFound(news.ErrNotFound)
+		return
+	}
+
+	rs, err := news.GetRevisions(id)
+	if err != nil {
+		log.Println(err)
+		writer.Error(err)
+		return
+	}
+
+	writer.Success(rs)
+}
+
+// DiffRevisionsHandler : get difference between two revisions, using from and to parameters
+func DiffRevisionsHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	writer := writer_lib.New(w)
+
+	if !news.IsValidRole(r.Header.Get(roleHeader)) {
+		writer.Forbidden(news.ErrInvalidRole)
+		return
+	}
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		writer.NotFound(news.ErrNotFound)
+		return
+	}
+
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil {
+		writer.BadRequest(fmt.Errorf("Invalid from revision"))
+		return
+	}
+
+	to, err := strconv.Atoi(r.URL.Query().Get("to"))
+	if err != nil {
+		writer.BadRequest(fmt.Errorf("Invalid to revision"))
+		return
+	}
+
+	d, err := news.DiffRevisions(id, from, to)
+	if err == news.ErrRevisionNotFound {
+		writer.NotFound(err)
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		writer.Error(err)
+		return
+	}
+
+	writer.Success(d)
+}
+
+// RestoreRevisionHandler : restore news content to the revision, processed by consumer as normal update
+func RestoreRevisionHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	writer := writer_lib.New(w)
+
+	if !news.IsValidRole(r.Header.Get(roleHeader)) {
+		writer.Forbidden(news.ErrInvalidRole)
+		return
+	}
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		writer.NotFound(news.ErrNotFound)
+		return
+	}
+
+	rev, err := strconv.Atoi(ps.ByName("rev"))
+	if err != nil {
+		writer.NotFound(news.ErrRevisionNotFound)
+		return
+	}
+
+	err = news.RestoreRevision(id, rev, r.Header.Get(userHeader))
+	if err == news.ErrRevisionNotFound {
+		writer.NotFound(err)
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		writer.Error(err)
+		return
+	}
+
+	writer.Success(nil)
+}
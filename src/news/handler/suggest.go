@@ -0,0 +1,67 @@
+// Package handler contains http handlers and NSQ consumers
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/filiadielias/kmpr-test/src/helper/cache"
+	writer_lib "github.com/filiadielias/kmpr-test/src/helper/writer"
+	"github.com/filiadielias/kmpr-test/src/news"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// Suggestions are requested on every keystroke, cache them briefly
+// instead of clearing the cache when news changed
+const suggestCacheTTL = 30 * time.Second
+
+// GetSuggestHandler : get title and author suggestions for search-as-you-type
+func GetSuggestHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	writer := writer_lib.New(w)
+
+	prefix, err := news.NormalizePrefix(r.URL.Query().Get("prefix"))
+	if err != nil {
+		writer.BadRequest(err)
+		return
+	}
+
+	size, err := strconv.Atoi(r.URL.Query().Get("size"))
+	if err != nil || size <= 0 {
+		size = news.DefaultSuggestSize
+	}
+	if size > news.MaxSuggestSize {
+		size = news.MaxSuggestSize
+	}
+
+	var ss news.Suggestions
+
+	// Check cache
+	key := fmt.Sprintf("news:suggest:size:%d:%s", size, prefix)
+	err = kmpr.Cache.Get(key, &ss)
+	if err == nil {
+		writer.Success(ss)
+		return
+	}
+	if err != cache.ErrMiss {
+		log.Println(err)
+	}
+
+	ss, err = news.GetSuggestions(prefix, size)
+	if err != nil {
+		log.Println(err)
+		writer.Error(err)
+		return
+	}
+
+	// Store to cache server
+	if err := kmpr.Cache.Set(key, ss, suggestCacheTTL); err != nil {
+		// Just display the error
+		log.Println(err)
+	}
+
+	writer.Success(ss)
+}
@@ -0,0 +1,139 @@
+// Package handler contains http handlers and NSQ consumers
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/filiadielias/kmpr-test/src/helper/cache"
+	"github.com/filiadielias/kmpr-test/src/helper/proxy"
+	writer_lib "github.com/filiadielias/kmpr-test/src/helper/writer"
+	"github.com/filiadielias/kmpr-test/src/news"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// Default trending window
+const defaultTrendingWindow = "24h"
+
+// Published status is cached for counting views, it is deleted when the status changed
+const publishedCacheTTL = time.Minute
+
+// AddViewHandler : count a view of published news
+func AddViewHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	writer := writer_lib.New(w)
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		writer.NotFound(news.ErrNotFound)
+		return
+	}
+
+	published, err := isPublished(id)
+	if err != nil {
+		log.Println(err)
+		writer.Error(err)
+		return
+	}
+	if !published {
+		writer.NotFound(news.ErrNotFound)
+		return
+	}
+
+	err = news.AddView(id, visitorOf(r))
+	if err == news.ErrInvalidVisitor {
+		writer.BadRequest(err)
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		writer.Error(err)
+		return
+	}
+
+	writer.Success(nil)
+}
+
+// GetTrendingHandler : get trending news in the window (1h, 24h or 7d)
+func GetTrendingHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	writer := writer_lib.New(w)
+
+	window := r.URL.Query().Get("window")
+	if len(window) == 0 {
+		window = defaultTrendingWindow
+	}
+	if !news.IsValidWindow(window) {
+		writer.BadRequest(news.ErrInvalidWindow)
+		return
+	}
+
+	size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+
+	ts, err := news.GetTrending(window, size)
+	if err != nil {
+		log.Println(err)
+		writer.Error(err)
+		return
+	}
+
+	writer.Success(ts)
+}
+
+// Check if news is published, the result is cached so counting views does not query database
+func isPublished(id int) (published bool, err error) {
+	key := publishedCacheKey(id)
+	if err := kmpr.Cache.Get(key, &published); err == nil {
+		return published, nil
+	} else if err != cache.ErrMiss {
+		log.Println(err)
+	}
+
+	published, err = news.IsPublished(id)
+	if err != nil {
+		return published, err
+	}
+
+	if err := kmpr.Cache.Set(key, published, publishedCacheTTL); err != nil {
+		log.Println(err)
+	}
+
+	return published, nil
+}
+
+func publishedCacheKey(id int) string {
+	return fmt.Sprintf("news:published:%d", id)
+}
+
+// Get visitor identity for counting unique visitors. Headers are set by api gateway,
+// so they are used only if the request comes from trusted proxy:
+// user id if logged in, otherwise client ip address (the last untrusted address of X-Forwarded-For)
+func visitorOf(r *http.Request) string {
+	host := proxy.RemoteHost(r)
+	if !kmpr.TrustedProxies.Contains(host) {
+		return "ip:" + host
+	}
+
+	if user := r.Header.Get(userHeader); len(user) > 0 {
+		return "user:" + user
+	}
+
+	// Each proxy appends the address it receives the request from,
+	// addresses before the last untrusted address can be set by the client
+	addresses := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(addresses) - 1; i >= 0; i-- {
+		address := strings.TrimSpace(addresses[i])
+		if len(address) == 0 {
+			continue
+		}
+		if !kmpr.TrustedProxies.Contains(address) {
+			return "ip:" + address
+		}
+		host = address
+	}
+
+	return "ip:" + host
+}
@@ -3,6 +3,9 @@ package news
 
 import (
 	//"log"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
 	"fmt"
 	"time"
 
@@ -11,7 +14,7 @@ import (
 	"github.com/filiadielias/kmpr-test/src/helper/db"
 
 	// PostgreSQL driver
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 var kmpr *general.Module
@@ -22,7 +25,39 @@ func init() {
 
 // Error list
 var (
-	ErrNotFound = fmt.Errorf("Data not Found")
+	ErrNotFound          = fmt.Errorf("Data not Found")
+	ErrTitleTooLong      = fmt.Errorf("Title must not be longer than 255 characters")
+	ErrInvalidStatus     = fmt.Errorf("Invalid status")
+	ErrInvalidRole       = fmt.Errorf("Invalid role")
+	ErrInvalidTransition = fmt.Errorf("Status change is not allowed")
+	ErrForbidden         = fmt.Errorf("Role is not allowed to change the status")
+	ErrInvalidPublishAt  = fmt.Errorf("Publish time must be in the future")
+	ErrNotScheduled      = fmt.Errorf("News is not scheduled at the given time")
+	ErrRevisionNotFound  = fmt.Errorf("Revision not Found")
+	ErrInvalidCursor     = fmt.Errorf("Invalid cursor")
+	ErrInvalidFromDate   = fmt.Errorf("Invalid from date, use YYYY-MM-DD or RFC3339 format")
+	ErrInvalidToDate     = fmt.Errorf("Invalid to date, use YYYY-MM-DD or RFC3339 format")
+	ErrInvalidDateRange  = fmt.Errorf("From date must be before to date")
+	ErrInvalidSort       = fmt.Errorf("Invalid sort, use created_desc or created_asc")
+	ErrInvalidAuthor     = fmt.Errorf("Author name or existing author id is required")
+	ErrInvalidTaxonomy   = fmt.Errorf("Category and tag names must not be longer than 100 characters, at most 20 tags")
+	ErrInvalidPrefix     = fmt.Errorf("Prefix must not be empty or longer than 50 characters")
+	ErrInvalidWindow     = fmt.Errorf("Invalid window, use 1h, 24h or 7d")
+	ErrInvalidVisitor    = fmt.Errorf("Visitor must not be empty")
+	ErrSearchUnavailable = fmt.Errorf("Search is not available")
+	ErrStopped           = fmt.Errorf("Stopped before finished")
+)
+
+// Elasticsearch date format, make sure the time has 6-digit fractional second
+const esDateFormat = "2006-01-02 15:04:05.000000"
+
+// MaxPublishDelay is maximum delay of NSQ deferred message (nsqd max-req-timeout)
+const MaxPublishDelay = time.Hour
+
+// Slug settings. Used slug gets random suffix, retry is needed only if the suffix is also used
+const (
+	maxSlugLength = 80
+	maxSlugRetry  = 5
 )
 
 // Newses is collection of News
@@ -30,26 +65,216 @@ type Newses []News
 
 // News represents news information
 type News struct {
-	ID      int       `json:"id" db:"id"`
-	Author  string    `json:"author" db:"author"`
-	Body    string    `json:"body" db:"body"`
-	Created time.Time `json:"created" db:"created"`
+	ID          int            `json:"id" db:"id"`
+	AuthorID    int            `json:"author_id" db:"author_id"`
+	Author      string         `json:"author" db:"author"`
+	Title       string         `json:"title" db:"title"`
+	Slug        string         `json:"slug" db:"slug"`
+	Summary     string         `json:"summary" db:"summary"`
+	Body        string         `json:"body" db:"body"`
+	CategoryID  int            `json:"category_id" db:"category_id"`
+	Category    string         `json:"category" db:"category"` // category name
+	Tags        pq.StringArray `json:"tags" db:"tags"`         // tag names
+	Status      string         `json:"status" db:"status"`
+	PublishAt   *time.Time     `json:"publish_at,omitempty" db:"publish_at"` // scheduled or actual publish time
+	Created     time.Time      `json:"created" db:"created"`
+	DeletedAt   *time.Time     `json:"deleted_at,omitempty" db:"deleted_at"`
+	Views       int            `json:"views" db:"views"`               // flushed periodically from redis
+	UniqueViews int            `json:"unique_views" db:"unique_views"` // estimated unique visitors
+	Editor      string         `json:"-" db:"-"`                       // user who adds or updates the news, stored in revision
 }
 
-// Insert news
+// Insert news, if slug already used add random suffix to the slug (e.g. title-3f9a2c)
 func (n *News) insert() error {
+	base := n.Slug
+
+	for i := 1; ; i++ {
+		tx := kmpr.DB.MustBegin()
+		err := tx.QueryRowx("INSERT INTO news(author_id,author,title,slug,summary,body,status) values($1,$2,$3,$4,$5,$6,$7) returning id,created",
+			n.AuthorID, n.Author, n.Title, n.Slug, n.Summary, n.Body, n.Status).Scan(&n.ID, &n.Created)
+		if err != nil {
+			tx.Rollback()
+
+			// Retry with another slug
+			if isSlugConflict(err) && i <= maxSlugRetry {
+				suffix, err := slugSuffix()
+				if err != nil {
+					return err
+				}
+
+				n.Slug = fmt.Sprintf("%s-%s", base, suffix)
+				continue
+			}
+			return err
+		}
+
+		if err := n.insertRevision(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err := n.saveTaxonomy(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		return nil
+	}
+}
+
+// Update news content and save it as new revision, status and slug are not changed
+func (n *News) update() error {
 	tx := kmpr.DB.MustBegin()
-	tx.QueryRowx("INSERT INTO news(author,body) values($1,$2) returning id,created", n.Author, n.Body).Scan(&n.ID, &n.Created)
 
-	if err := tx.Commit(); err != nil {
+	// Lock the news, so revision number is not used by other update
+	err := tx.QueryRowx("UPDATE news SET author_id=$1, author=$2, title=$3, summary=$4, body=$5 WHERE id=$6 AND deleted_at IS NULL "+
+		"returning slug,status,publish_at,created",
+		n.AuthorID, n.Author, n.Title, n.Summary, n.Body, n.ID).Scan(&n.Slug, &n.Status, &n.PublishAt, &n.Created)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	if err := n.insertRevision(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := n.saveTaxonomy(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Update news status, only if the status is not changed by other process.
+// publish_at is not changed if publishAt is nil
+func (n *News) updateStatus(status string, publishAt *time.Time) error {
+	res, err := kmpr.DB.Exec("UPDATE news SET status=$1, publish_at=coalesce($2, publish_at) WHERE id=$3 AND status=$4 AND deleted_at IS NULL",
+		status, publishAt, n.ID, n.Status)
+	if err != nil {
 		return err
 	}
+
+	if count, err := res.RowsAffected(); err != nil || count == 0 {
+		return ErrInvalidTransition
+	}
+
+	n.Status = status
+	if publishAt != nil {
+		n.PublishAt = publishAt
+	}
 	return nil
 }
 
+// Random slug suffix, 6 hex characters
+func slugSuffix() (string, error) {
+	b := make([]byte, 3)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// Soft delete the news, deleted news is moved to trash
+func (n *News) delete() error {
+	err := kmpr.DB.QueryRowx("UPDATE news SET deleted_at=now() WHERE id=$1 AND deleted_at IS NULL returning status,deleted_at",
+		n.ID).Scan(&n.Status, &n.DeletedAt)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	return err
+}
+
+// Restore the news from trash
+func (n *News) restore() error {
+	err := kmpr.DB.QueryRowx("UPDATE news SET deleted_at=NULL WHERE id=$1 AND deleted_at IS NOT NULL returning id", n.ID).Scan(&n.ID)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	n.DeletedAt = nil
+	return nil
+}
+
+// Permanently delete news which are deleted longer than retention, including the revisions.
+// Time is compared in database, so it is not affected by application timezone
+func purge(retention time.Duration, stop <-chan struct{}) (count int64, err error) {
+	interval := fmt.Sprintf("%d seconds", int64(retention.Seconds()))
+
+	tx := kmpr.DB.MustBegin()
+
+	for _, table := range []string{"news_revisions", "news_tags"} {
+		_, err = tx.Exec("DELETE FROM "+table+" WHERE news_id IN "+
+			"(SELECT id FROM news WHERE deleted_at < now() - $1::interval)", interval)
+		if err != nil {
+			tx.Rollback()
+			return count, err
+		}
+	}
+
+	res, err := tx.Exec("DELETE FROM news WHERE deleted_at < now() - $1::interval", interval)
+	if err != nil {
+		tx.Rollback()
+		return count, err
+	}
+
+	if stopped(stop) {
+		tx.Rollback()
+		return count, ErrStopped
+	}
+
+	if err := tx.Commit(); err != nil {
+		return count, err
+	}
+
+	return res.RowsAffected()
+}
+
+// Add flushed view count and set unique visitors estimation
+func (n *News) updateViews(views, uniqueViews int) error {
+	_, err := kmpr.DB.Exec("UPDATE news SET views=views+$1, unique_views=$2 WHERE id=$3", views, uniqueViews, n.ID)
+	return err
+}
+
+// Check if error is caused by duplicate slug
+func isSlugConflict(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505" && pqErr.Constraint == "news_slug_key"
+}
+
+// Get news from database, deleted news are excluded
 func (ns *Newses) getFromDB(qb *db.QueryBuilder) error {
+	qb.AddFilter("deleted_at", nil, "is null")
 
-	qb.Query = "select id,author,body,created from news"
+	return ns.query(qb)
+}
+
+// Get deleted news (trash) from database
+func (ns *Newses) getDeletedFromDB(qb *db.QueryBuilder) error {
+	qb.AddFilter("deleted_at", nil, "is not null")
+
+	return ns.query(qb)
+}
+
+func (ns *Newses) query(qb *db.QueryBuilder) error {
+
+	qb.Query = "select id,coalesce(author_id,0) as author_id,author,title,slug,summary,body,status,publish_at,created,deleted_at,views,unique_views," +
+		"coalesce(category_id,0) as category_id," +
+		"coalesce((select c.name from categories c where c.id = news.category_id),'') as category," +
+		"array(select t.name from news_tags nt join tags t on t.id = nt.tag_id where nt.news_id = news.id order by t.name) as tags " +
+		"from news"
 
 	query, params := qb.GetQuery()
 
@@ -57,6 +282,7 @@ func (ns *Newses) getFromDB(qb *db.QueryBuilder) error {
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var n News
@@ -75,6 +301,17 @@ func (ns *Newses) getFromDB(qb *db.QueryBuilder) error {
 	return nil
 }
 
+// Count news in database, deleted news are excluded
+func (ns *Newses) countFromDB(qb *db.QueryBuilder) (total int, err error) {
+	qb.AddFilter("deleted_at", nil, "is null")
+	qb.Query = "select count(*) from news"
+
+	query, params := qb.GetCountQuery()
+
+	err = kmpr.DB.QueryRowx(query, params...).Scan(&total)
+	return total, err
+}
+
 func (n *News) getFromDB(qb *db.QueryBuilder) error {
 
 	// Call Newses get function
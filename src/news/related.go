@@ -0,0 +1,62 @@
+// Package news contains business logic from news, store to database, etc
+package news
+
+import (
+	"strconv"
+
+	"github.com/filiadielias/kmpr-test/src/helper/elastic"
+)
+
+// Related news settings
+const (
+	DefaultRelatedSize = 5
+	MaxRelatedSize     = 10
+)
+
+// GetRelatedNews getting published news which are similar to the news (title, body and tags),
+// the news itself is excluded
+func GetRelatedNews(id, size int) (ns Newses, err error) {
+	if size <= 0 {
+		size = DefaultRelatedSize
+	}
+	if size > MaxRelatedSize {
+		size = MaxRelatedSize
+	}
+
+	// Only published news is indexed
+	n, err := getPublished(id)
+	if err != nil {
+		return ns, err
+	}
+
+	eq := elastic.Query{}
+	eq.Limit = size
+	eq.AddMust(map[string]interface{}{
+		"more_like_this": map[string]interface{}{
+			"fields": []string{"title", "body", "tags"},
+			"like": []map[string]interface{}{
+				{"_index": "news", "_id": strconv.Itoa(n.ID)},
+			},
+			// News is short, use every term instead of frequent terms only
+			"min_term_freq":   1,
+			"min_doc_freq":    1,
+			"max_query_terms": 25,
+			"include":         false,
+		},
+	})
+
+	ids, _, err := searchIDs(&eq)
+	if err != nil {
+		return ns, err
+	}
+
+	ns, err = getByIDs(ids)
+	if err != nil {
+		return ns, err
+	}
+
+	if ns == nil {
+		ns = Newses{}
+	}
+	return ns, nil
+}
@@ -0,0 +1,176 @@
+// Package news contains business logic from news, store to database, etc
+package news
+
+import (
+	"time"
+
+	"github.com/filiadielias/kmpr-test/src/helper/db"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Revisions is collection of Revision
+type Revisions []Revision
+
+// Revision is a version of news content, created on every insert and update
+type Revision struct {
+	ID       int       `json:"id" db:"id"`
+	NewsID   int       `json:"news_id" db:"news_id"`
+	Rev      int       `json:"rev" db:"rev"`
+	AuthorID int       `json:"author_id" db:"author_id"`
+	Author   string    `json:"author" db:"author"`
+	Title    string    `json:"title" db:"title"`
+	Summary  string    `json:"summary" db:"summary"`
+	Body     string    `json:"body" db:"body"`
+	Editor   string    `json:"editor" db:"editor"`
+	Created  time.Time `json:"created" db:"created"`
+}
+
+// Insert revision of the news content using the news transaction,
+// revision number is the next number of the news revisions
+func (n *News) insertRevision(tx *sqlx.Tx) error {
+	_, err := tx.Exec("INSERT INTO news_revisions(news_id,rev,author_id,author,title,summary,body,editor) "+
+		"SELECT $1, coalesce(max(rev),0)+1, nullif($2,0), $3, $4, $5, $6, $7 FROM news_revisions WHERE news_id=$1",
+		n.ID, n.AuthorID, n.Author, n.Title, n.Summary, n.Body, n.Editor)
+	return err
+}
+
+func (rs *Revisions) getFromDB(qb *db.QueryBuilder) error {
+
+	qb.Query = "select id,news_id,rev,coalesce(author_id,0) as author_id,author,title,summary,body,editor,created from news_revisions"
+
+	query, params := qb.GetQuery()
+
+	rows, err := kmpr.DB.Queryx(query, params...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r Revision
+
+		if err := rows.StructScan(&r); err != nil {
+			return err
+		}
+
+		*rs = append(*rs, r)
+	}
+
+	if len(*rs) == 0 {
+		return ErrRevisionNotFound
+	}
+
+	return nil
+}
+
+func (r *Revision) getFromDB(qb *db.QueryBuilder) error {
+
+	// Call Revisions get function
+	var rs Revisions
+	if err := rs.getFromDB(qb); err != nil {
+		return err
+	}
+
+	*r = rs[0]
+
+	return nil
+}
+
+// GetRevisions getting all revisions of the news, newest first
+func GetRevisions(newsID int) (rs Revisions, err error) {
+	qb := db.QueryBuilder{}
+	qb.AddFilter("news_id", newsID, "=")
+	qb.AddSort("rev", "desc")
+
+	if err := rs.getFromDB(&qb); err != nil && err != ErrRevisionNotFound {
+		return rs, err
+	}
+
+	return rs, nil
+}
+
+// GetRevision getting revision of the news by revision number
+func GetRevision(newsID, rev int) (r Revision, err error) {
+	qb := db.QueryBuilder{}
+	qb.AddFilter("news_id", newsID, "=")
+	qb.AddFilter("rev", rev, "=")
+
+	err = r.getFromDB(&qb)
+	return r, err
+}
+
+// RevisionDiff is difference between two revisions
+type RevisionDiff struct {
+	From    int        `json:"from"`
+	To      int        `json:"to"`
+	Author  *FieldDiff `json:"author,omitempty"`
+	Title   *FieldDiff `json:"title,omitempty"`
+	Summary *FieldDiff `json:"summary,omitempty"`
+	Body    []DiffLine `json:"body"`
+}
+
+// FieldDiff is old and new value of changed field
+type FieldDiff struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// DiffRevisions getting difference between two revisions of the news,
+// single line fields are compared as a whole, body is compared line by line
+func DiffRevisions(newsID, from, to int) (d RevisionDiff, err error) {
+	a, err := GetRevision(newsID, from)
+	if err != nil {
+		return d, err
+	}
+
+	b, err := GetRevision(newsID, to)
+	if err != nil {
+		return d, err
+	}
+
+	d.From = from
+	d.To = to
+	d.Author = diffField(a.Author, b.Author)
+	d.Title = diffField(a.Title, b.Title)
+	d.Summary = diffField(a.Summary, b.Summary)
+	d.Body = diffLines(a.Body, b.Body)
+
+	return d, nil
+}
+
+// RestoreRevision to restore news content to the revision,
+// restored content is saved as a new revision through the update pipeline.
+// Category and tags are not part of revision, the current values are kept
+func RestoreRevision(newsID, rev int, editor string) error {
+	r, err := GetRevision(newsID, rev)
+	if err != nil {
+		return err
+	}
+
+	var n News
+	qb := db.QueryBuilder{}
+	qb.AddFilter("id", newsID, "=")
+	if err := n.getFromDB(&qb); err != nil {
+		return err
+	}
+
+	return EditNews(News{
+		ID:       r.NewsID,
+		AuthorID: r.AuthorID,
+		Author:   r.Author,
+		Title:    r.Title,
+		Summary:  r.Summary,
+		Body:     r.Body,
+		Category: n.Category,
+		Tags:     n.Tags,
+		Editor:   editor,
+	})
+}
+
+func diffField(from, to string) *FieldDiff {
+	if from == to {
+		return nil
+	}
+	return &FieldDiff{From: from, To: to}
+}
@@ -0,0 +1,80 @@
+// Package news contains business logic from news, store to database, etc
+package news
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/filiadielias/kmpr-test/src/helper/breaker"
+	"github.com/filiadielias/kmpr-test/src/helper/elastic"
+)
+
+// Elasticsearch is not called for cooldown after threshold consecutive failures
+const (
+	searchBreakerThreshold = 5
+	searchBreakerCooldown  = 30 * time.Second
+)
+
+var searchBreaker = newSearchBreaker()
+
+func newSearchBreaker() *breaker.Breaker {
+	b := breaker.New(searchBreakerThreshold, searchBreakerCooldown)
+	b.OnStateChange = func(open bool, err error) {
+		if open {
+			log.Printf("elasticsearch is not used for %s: %v", searchBreakerCooldown, err)
+		} else {
+			log.Println("elasticsearch is available")
+		}
+	}
+
+	return b
+}
+
+// SearchAvailable : check if elasticsearch is used, news are taken from database (degraded) if it is not
+func SearchAvailable() bool {
+	return !searchBreaker.Open()
+}
+
+// Search news index, returns ErrSearchUnavailable if elasticsearch is not available
+func search(eq *elastic.Query) (r elastic.SearchResult, err error) {
+	if !searchBreaker.Allow() {
+		return r, ErrSearchUnavailable
+	}
+
+	r, err = elastic.Search(kmpr.ES, "news", eq)
+	if isUnavailable(err) {
+		searchBreaker.Failure(err)
+	} else {
+		searchBreaker.Success()
+	}
+
+	return r, err
+}
+
+// Search news index, returns news ids and total hits
+func searchIDs(eq *elastic.Query) (ids []int, total int, err error) {
+	r, err := search(eq)
+	if err != nil {
+		return ids, total, err
+	}
+
+	for _, hit := range r.Hits {
+		id, _ := strconv.Atoi(hit.ID)
+
+		ids = append(ids, id)
+	}
+
+	return ids, r.Total, nil
+}
+
+// Check if error is caused by elasticsearch (not the query), e.g. not reachable, timeout, overloaded
+func isUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var e *elastic.Error
+	return !errors.As(err, &e) || e.Status == 0 || e.Status >= 500 || e.Status == 429 || elastic.IsIndexNotFound(err)
+}
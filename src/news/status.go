@@ -0,0 +1,76 @@
+// Package news contains business logic from news, store to database, etc
+package news
+
+// List of news status
+const (
+	StatusDraft     = "draft"
+	StatusInReview  = "in_review"
+	StatusScheduled = "scheduled"
+	StatusPublished = "published"
+	StatusArchived  = "archived"
+)
+
+// List of user roles
+const (
+	RoleWriter = "writer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// transitions : allowed status changes and roles allowed to do it, from -> to -> roles
+var transitions = map[string]map[string][]string{
+	StatusDraft: {
+		StatusInReview: {RoleWriter, RoleEditor, RoleAdmin},
+	},
+	StatusInReview: {
+		StatusDraft:     {RoleEditor, RoleAdmin}, // rejected
+		StatusScheduled: {RoleEditor, RoleAdmin},
+		StatusPublished: {RoleEditor, RoleAdmin},
+	},
+	StatusScheduled: {
+		StatusInReview:  {RoleEditor, RoleAdmin}, // unscheduled
+		StatusPublished: {RoleEditor, RoleAdmin},
+	},
+	StatusPublished: {
+		StatusArchived: {RoleEditor, RoleAdmin},
+	},
+	StatusArchived: {
+		StatusPublished: {RoleAdmin},
+	},
+}
+
+// IsValidStatus : check if status is known
+func IsValidStatus(status string) bool {
+	_, ok := transitions[status]
+	return ok
+}
+
+// IsValidRole : check if role is known
+func IsValidRole(role string) bool {
+	return role == RoleWriter || role == RoleEditor || role == RoleAdmin
+}
+
+// CanManageTrash : check if the role can delete news, list deleted news and restore them
+func CanManageTrash(role string) bool {
+	return role == RoleEditor || role == RoleAdmin
+}
+
+// checkTransition : check if status change is allowed for the role
+func checkTransition(from, to, role string) error {
+	if !IsValidStatus(to) {
+		return ErrInvalidStatus
+	}
+
+	roles, ok := transitions[from][to]
+	if !ok {
+		return ErrInvalidTransition
+	}
+
+	for _, r := range roles {
+		if r == role {
+			return nil
+		}
+	}
+
+	return ErrForbidden
+}
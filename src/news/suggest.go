@@ -0,0 +1,97 @@
+// Package news contains business logic from news, store to database, etc
+package news
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/filiadielias/kmpr-test/src/helper/elastic"
+)
+
+// Suggestion settings
+const (
+	DefaultSuggestSize = 5
+	MaxSuggestSize     = 10
+	maxPrefixLength    = 50
+)
+
+// Suggestion is published news which title or author starts with the prefix
+type Suggestion struct {
+	ID     int    `json:"id"`
+	Text   string `json:"text"` // matched title or author
+	Title  string `json:"title"`
+	Slug   string `json:"slug"`
+	Author string `json:"author"`
+}
+
+// Suggestions is collection of Suggestion
+type Suggestions []Suggestion
+
+// NormalizePrefix : trim and lowercase the prefix, completion suggester is case insensitive
+func NormalizePrefix(prefix string) (string, error) {
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	if len(prefix) == 0 || len([]rune(prefix)) > maxPrefixLength {
+		return prefix, ErrInvalidPrefix
+	}
+
+	return prefix, nil
+}
+
+// GetSuggestions getting suggestions for search-as-you-type, using news title and author
+func GetSuggestions(prefix string, size int) (ss Suggestions, err error) {
+	prefix, err = NormalizePrefix(prefix)
+	if err != nil {
+		return ss, err
+	}
+
+	if size <= 0 {
+		size = DefaultSuggestSize
+	}
+	if size > MaxSuggestSize {
+		size = MaxSuggestSize
+	}
+
+	eq := elastic.Query{}
+	eq.SkipHits = true
+	eq.AddCompletionSuggest("news", "suggest", prefix, size)
+
+	r, err := search(&eq)
+	if err != nil {
+		return ss, err
+	}
+
+	ss = Suggestions{}
+	for _, opt := range r.Suggestions("news") {
+		var doc struct {
+			Title  string `json:"title"`
+			Slug   string `json:"slug"`
+			Author string `json:"author"`
+		}
+		if err := opt.DecodeSource(&doc); err != nil {
+			return ss, err
+		}
+
+		id, _ := strconv.Atoi(opt.ID)
+		ss = append(ss, Suggestion{
+			ID:     id,
+			Text:   opt.Text,
+			Title:  doc.Title,
+			Slug:   doc.Slug,
+			Author: doc.Author,
+		})
+	}
+
+	return ss, nil
+}
+
+// Completion suggester inputs of the news, empty title and author are not suggested
+func (n *News) suggestInput() []string {
+	input := []string{}
+	for _, s := range []string{n.Title, n.Author} {
+		if len(strings.TrimSpace(s)) > 0 {
+			input = append(input, s)
+		}
+	}
+
+	return input
+}
@@ -0,0 +1,120 @@
+// Package news contains business logic from news, store to database, etc
+package news
+
+import (
+	"unicode/utf8"
+
+	"github.com/filiadielias/kmpr-test/src/general"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Category and tag limits, names are stored as varchar(100) (slug is not longer than the name)
+const (
+	maxTaxonomyNameLength = 100
+	maxTags               = 20
+)
+
+// Check category and tags before publishing to consumer, consumer can not save longer names
+func (n *News) validateTaxonomy() error {
+	if len(n.Tags) > maxTags {
+		return ErrInvalidTaxonomy
+	}
+
+	for _, name := range append([]string{n.Category}, n.Tags...) {
+		if utf8.RuneCountInString(name) > maxTaxonomyNameLength {
+			return ErrInvalidTaxonomy
+		}
+	}
+
+	return nil
+}
+
+// Save news category and tags using the news transaction. Categories and tags are matched by slug,
+// and created if not exists. Category and tag names are replaced by the stored names
+func (n *News) saveTaxonomy(tx *sqlx.Tx) error {
+	n.CategoryID = 0
+	if slug := general.Slugify(n.Category); len(slug) > 0 {
+		err := tx.QueryRowx("INSERT INTO categories(name,slug) values($1,$2) "+
+			"ON CONFLICT (slug) DO UPDATE SET slug = EXCLUDED.slug returning id,name",
+			n.Category, slug).Scan(&n.CategoryID, &n.Category)
+		if err != nil {
+			return err
+		}
+	} else {
+		n.Category = ""
+	}
+
+	if _, err := tx.Exec("UPDATE news SET category_id=nullif($1,0) WHERE id=$2", n.CategoryID, n.ID); err != nil {
+		return err
+	}
+
+	// Replace all tags
+	if _, err := tx.Exec("DELETE FROM news_tags WHERE news_id=$1", n.ID); err != nil {
+		return err
+	}
+
+	var tags pq.StringArray
+	used := map[string]bool{}
+	for _, name := range n.Tags {
+		slug := general.Slugify(name)
+		if len(slug) == 0 || used[slug] {
+			continue
+		}
+		used[slug] = true
+
+		var id int
+		err := tx.QueryRowx("INSERT INTO tags(name,slug) values($1,$2) "+
+			"ON CONFLICT (slug) DO UPDATE SET slug = EXCLUDED.slug returning id,name",
+			name, slug).Scan(&id, &name)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec("INSERT INTO news_tags(news_id,tag_id) values($1,$2)", n.ID, id); err != nil {
+			return err
+		}
+
+		tags = append(tags, name)
+	}
+	n.Tags = tags
+
+	return nil
+}
+
+// Get category or tag names by slugs, table is categories or tags
+func getTaxonomyNames(table string, slugs []string) (map[string]string, error) {
+	names := map[string]string{}
+	if len(slugs) == 0 {
+		return names, nil
+	}
+
+	var rows []struct {
+		Slug string `db:"slug"`
+		Name string `db:"name"`
+	}
+
+	err := kmpr.DB.Select(&rows, "select slug,name from "+table+" where slug = any($1)", pq.Array(slugs))
+	if err != nil {
+		return names, err
+	}
+
+	for _, r := range rows {
+		names[r.Slug] = r.Name
+	}
+
+	return names, nil
+}
+
+// Get category and tag slugs of the news, used for elasticsearch document
+func (n *News) taxonomySlugs() (category string, tags []string) {
+	category = general.Slugify(n.Category)
+
+	tags = []string{}
+	for _, t := range n.Tags {
+		tags = append(tags, general.Slugify(t))
+	}
+
+	return category, tags
+}
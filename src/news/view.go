@@ -0,0 +1,321 @@
+// Package news contains business logic from news, store to database, etc
+package news
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/filiadielias/kmpr-test/src/helper/breaker"
+	"github.com/filiadielias/kmpr-test/src/helper/db"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// Trending settings
+const (
+	DefaultTrendingSize = 10
+	MaxTrendingSize     = 50
+	// Ranking is cached, so sorted sets are not merged on every request
+	trendingCacheTTL = time.Minute
+	// Number of dirty news flushed per redis call
+	flushBatchSize = 100
+)
+
+// Redis keys of view counters
+const (
+	viewDirtyKey = "news:views:dirty" // news which have views not flushed to database
+)
+
+// Redis is not called by views and trending for cooldown after threshold consecutive failures
+const (
+	viewBreakerThreshold = 5
+	viewBreakerCooldown  = 10 * time.Second
+)
+
+var viewBreaker = newViewBreaker()
+
+func newViewBreaker() *breaker.Breaker {
+	b := breaker.New(viewBreakerThreshold, viewBreakerCooldown)
+	b.OnStateChange = func(open bool, err error) {
+		if open {
+			log.Printf("redis is not used for views for %s: %v", viewBreakerCooldown, err)
+		} else {
+			log.Println("redis is available for views")
+		}
+	}
+
+	return b
+}
+
+// Record redis call result, error reply (e.g. wrong type) means redis is available
+func viewBreakerDone(err error) {
+	if _, ok := err.(redis.Error); ok {
+		err = nil
+	}
+	viewBreaker.Done(err)
+}
+
+// trendingWindow : views are counted per bucket, a window is the latest buckets.
+// Bucket weight is halved every half of the window (time decay)
+type trendingWindow struct {
+	bucket time.Duration
+	count  int
+}
+
+func (w trendingWindow) duration() time.Duration {
+	return w.bucket * time.Duration(w.count)
+}
+
+// weight of the i-th latest bucket, the current bucket weight is 1
+func (w trendingWindow) weight(i int) float64 {
+	halfLife := w.duration() / 2
+	return math.Pow(0.5, float64(w.bucket*time.Duration(i))/float64(halfLife))
+}
+
+var trendingWindows = map[string]trendingWindow{
+	"1h":  {bucket: 5 * time.Minute, count: 12},
+	"24h": {bucket: time.Hour, count: 24},
+	"7d":  {bucket: 6 * time.Hour, count: 28},
+}
+
+// TrendingNews is news with trending score
+type TrendingNews struct {
+	News
+	Score float64 `json:"score"`
+}
+
+// IsValidWindow : check if trending window is supported
+func IsValidWindow(window string) bool {
+	_, ok := trendingWindows[window]
+	return ok
+}
+
+func viewCountKey(id int) string {
+	return fmt.Sprintf("news:views:count:%d", id)
+}
+
+func viewUniqueKey(id int) string {
+	return fmt.Sprintf("news:views:unique:%d", id)
+}
+
+func trendingKey(window string, bucket time.Time) string {
+	return fmt.Sprintf("news:trending:%s:%d", window, bucket.Unix())
+}
+
+func trendingResultKey(window string) string {
+	return fmt.Sprintf("news:trending:result:%s", window)
+}
+
+// AddView counting a view of news, caller must check the news is published (IsPublished).
+// Visitor is used for counting unique visitors.
+// Views are stored in redis and flushed to database by FlushViews, the view is not counted if redis is not available
+func AddView(id int, visitor string) error {
+	if len(visitor) == 0 {
+		return ErrInvalidVisitor
+	}
+
+	if !viewBreaker.Allow() {
+		return nil
+	}
+
+	conn := kmpr.Redis.Get()
+	defer conn.Close()
+
+	now := time.Now()
+
+	conn.Send("MULTI")
+	conn.Send("INCR", viewCountKey(id))
+	conn.Send("PFADD", viewUniqueKey(id), visitor)
+	conn.Send("SADD", viewDirtyKey, id)
+	for name, w := range trendingWindows {
+		key := trendingKey(name, now.Truncate(w.bucket))
+		conn.Send("ZINCRBY", key, 1, id)
+		// Keep the bucket until it is out of the window
+		conn.Send("EXPIRE", key, int64((w.duration()+w.bucket)/time.Second))
+	}
+
+	// Counting views is best effort, news is still served if redis is not available
+	_, err := conn.Do("EXEC")
+	viewBreakerDone(err)
+	if err != nil {
+		log.Printf("error counting view of news %d: %v", id, err)
+	}
+
+	return nil
+}
+
+// GetTrending getting published news ranked by views in the window (1h, 24h or 7d),
+// recent views have higher weight. If redis is not available, most viewed news published in the window are returned
+func GetTrending(window string, size int) (ts []TrendingNews, err error) {
+	w, ok := trendingWindows[window]
+	if !ok {
+		return ts, ErrInvalidWindow
+	}
+
+	if size <= 0 {
+		size = DefaultTrendingSize
+	}
+	if size > MaxTrendingSize {
+		size = MaxTrendingSize
+	}
+
+	// Redis is not available, rank by views stored in database
+	if !viewBreaker.Allow() {
+		return getTrendingFromDB(w, size)
+	}
+
+	ids, scores, err := getTrendingFromRedis(window, w, size)
+	viewBreakerDone(err)
+	if err != nil {
+		log.Println(err)
+		return getTrendingFromDB(w, size)
+	}
+
+	ns, err := getByIDs(ids)
+	if err != nil {
+		return ts, err
+	}
+
+	ts = []TrendingNews{}
+	for _, n := range ns {
+		if n.Status != StatusPublished {
+			continue
+		}
+
+		ts = append(ts, TrendingNews{News: n, Score: scores[n.ID]})
+		if len(ts) == size {
+			break
+		}
+	}
+
+	return ts, nil
+}
+
+// Get trending news ids and scores from redis
+func getTrendingFromRedis(window string, w trendingWindow, size int) (ids []int, scores map[int]float64, err error) {
+	conn := kmpr.Redis.Get()
+	defer conn.Close()
+
+	key := trendingResultKey(window)
+	exists, err := redis.Bool(conn.Do("EXISTS", key))
+	if err != nil {
+		return ids, scores, err
+	}
+
+	// Merge the window buckets using decayed weights
+	if !exists {
+		now := time.Now()
+
+		args := redis.Args{}.Add(key, w.count)
+		weights := redis.Args{}.Add("WEIGHTS")
+		for i := 0; i < w.count; i++ {
+			args = args.Add(trendingKey(window, now.Truncate(w.bucket).Add(-w.bucket*time.Duration(i))))
+			weights = weights.Add(w.weight(i))
+		}
+
+		conn.Send("MULTI")
+		conn.Send("ZUNIONSTORE", append(args, weights...)...)
+		conn.Send("PEXPIRE", key, int64(trendingCacheTTL/time.Millisecond))
+		if _, err := conn.Do("EXEC"); err != nil {
+			return ids, scores, fmt.Errorf("error merging trending %s: %v", window, err)
+		}
+	}
+
+	// Get more than size, news which are no longer published are skipped
+	values, err := redis.Strings(conn.Do("ZREVRANGE", key, 0, size*2-1, "WITHSCORES"))
+	if err != nil {
+		return ids, scores, err
+	}
+
+	scores = map[int]float64{}
+	for i := 0; i+1 < len(values); i += 2 {
+		id, err := strconv.Atoi(values[i])
+		if err != nil {
+			continue
+		}
+		score, _ := strconv.ParseFloat(values[i+1], 64)
+
+		ids = append(ids, id)
+		scores[id] = score
+	}
+
+	return ids, scores, nil
+}
+
+// Get most viewed news which are published in the window, views are not weighted
+func getTrendingFromDB(w trendingWindow, size int) (ts []TrendingNews, err error) {
+	qb := db.QueryBuilder{}
+	qb.Limit = size
+	qb.AddFilter("status", StatusPublished, "=")
+	qb.AddFilter("publish_at", time.Now().Add(-w.duration()), ">=")
+	qb.AddSort("views", "desc")
+	qb.AddSort("id", "desc")
+
+	var ns Newses
+	if err := ns.getFromDB(&qb); err != nil && err != ErrNotFound {
+		return ts, err
+	}
+
+	ts = []TrendingNews{}
+	for _, n := range ns {
+		ts = append(ts, TrendingNews{News: n, Score: float64(n.Views)})
+	}
+
+	return ts, nil
+}
+
+// FlushViews storing view counters from redis to database, returns number of flushed news.
+// Remaining news are flushed later (ErrStopped) if stop is closed
+func FlushViews(stop <-chan struct{}) (count int, err error) {
+	conn := kmpr.Redis.Get()
+	defer conn.Close()
+
+	for {
+		ids, err := redis.Ints(conn.Do("SPOP", viewDirtyKey, flushBatchSize))
+		if err != nil {
+			return count, err
+		}
+		if len(ids) == 0 {
+			return count, nil
+		}
+
+		for i, id := range ids {
+			err := ErrStopped
+			if !stopped(stop) {
+				err = flushView(conn, id)
+			}
+			if err != nil {
+				// Flush the remaining news later
+				conn.Do("SADD", redis.Args{}.Add(viewDirtyKey).AddFlat(ids[i:])...)
+				return count, err
+			}
+			count++
+		}
+	}
+}
+
+// Move view count of the news from redis to database
+func flushView(conn redis.Conn, id int) error {
+	// Reset the counter, so views counted during flush are not lost
+	views, err := redis.Int(conn.Do("GETSET", viewCountKey(id), 0))
+	if err != nil && err != redis.ErrNil {
+		return err
+	}
+
+	uniqueViews, err := redis.Int(conn.Do("PFCOUNT", viewUniqueKey(id)))
+	if err != nil {
+		conn.Do("INCRBY", viewCountKey(id), views)
+		return err
+	}
+
+	n := News{ID: id}
+	if err := n.updateViews(views, uniqueViews); err != nil {
+		conn.Do("INCRBY", viewCountKey(id), views)
+		return err
+	}
+
+	return nil
+}